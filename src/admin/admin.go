@@ -0,0 +1,223 @@
+package admin
+
+
+import (
+    "encoding/json"
+    "net/http"
+    "server"
+    "strconv"
+    "strings"
+)
+
+// HTTP admin and monitoring API.
+//
+//  GET    /metrics                            Prometheus text format
+//  GET    /rooms                              all rooms with their clients
+//  GET    /rooms/{room}                       a single room
+//  POST   /rooms/{room}/reset                 same as master's :reset
+//  POST   /rooms/{room}/shutdown              disconnect everyone, close the room
+//  DELETE /rooms/{room}/master                revoke master
+//  POST   /rooms/{room}/clients/{id}/kick     optional form value "reason"
+//  POST   /rooms/{room}/clients/{id}/rename   form value "name"
+//  POST   /rooms/{room}/clients/{id}/master   grant master
+//  GET    /tournament                         bracket, results and standings
+type Admin struct {
+    server *server.Server
+    token string
+    mux *http.ServeMux
+}
+
+type route struct {
+    method string
+    // path segments, "*" matches anything
+    path []string
+    handler http.HandlerFunc
+}
+
+func New(s *server.Server, token string) *Admin {
+    admin := &Admin{server: s, token: token, mux: http.NewServeMux()}
+    admin.mux.Handle("/metrics", s.Metrics)
+    admin.mux.HandleFunc("/rooms", admin.listRooms)
+    admin.mux.HandleFunc("/tournament", admin.showTournament)
+    admin.mux.HandleFunc("/rooms/", admin.route([]route{
+        {"GET", []string{"rooms", "*"}, admin.withRoom(admin.showRoom)},
+        {"POST", []string{"rooms", "*", "reset"}, admin.withRoom(admin.resetRoom)},
+        {"POST", []string{"rooms", "*", "shutdown"}, admin.withRoom(admin.shutdownRoom)},
+        {"DELETE", []string{"rooms", "*", "master"}, admin.withRoom(admin.revokeMaster)},
+        {"POST", []string{"rooms", "*", "clients", "*", "kick"}, admin.withClient(admin.kick)},
+        {"POST", []string{"rooms", "*", "clients", "*", "rename"}, admin.withClient(admin.rename)},
+        {"POST", []string{"rooms", "*", "clients", "*", "master"}, admin.withClient(admin.grantMaster)},
+    }))
+    return admin
+}
+
+func pathSegments(r *http.Request) []string {
+    return strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+}
+
+func (rt route) matches(segments []string) bool {
+    if len(segments) != len(rt.path) {
+        return false
+    }
+    for i, segment := range rt.path {
+        if segment != "*" && segment != segments[i] {
+            return false
+        }
+    }
+    return true
+}
+
+// dispatches the request to the first route matching its method and path
+func (admin *Admin) route(routes []route) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        segments := pathSegments(r)
+        found := false
+        for _, rt := range routes {
+            if !rt.matches(segments) {
+                continue
+            }
+            found = true
+            if rt.method == r.Method {
+                rt.handler(w, r)
+                return
+            }
+        }
+        if found {
+            writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+        } else {
+            writeError(w, http.StatusNotFound, "not found")
+        }
+    }
+}
+
+// lets other subsystems serve their pages on the admin port
+func (admin *Admin) Handle(pattern string, handler http.Handler) {
+    admin.mux.Handle(pattern, handler)
+}
+
+func (admin *Admin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    if admin.token != "" && r.Header.Get("Authorization") != "Bearer " + admin.token {
+        writeError(w, http.StatusUnauthorized, "bad or missing token")
+        return
+    }
+    admin.mux.ServeHTTP(w, r)
+}
+
+func (admin *Admin) ListenAndServe(addr string) error {
+    return http.ListenAndServe(addr, admin)
+}
+
+type roomHandler func(w http.ResponseWriter, r *http.Request, game *server.Game)
+type clientHandler func(w http.ResponseWriter, r *http.Request, game *server.Game, id int)
+
+func (admin *Admin) withRoom(handler roomHandler) http.HandlerFunc {
+    return func(w http.ResponseWriter, r *http.Request) {
+        // /rooms/{room}/...
+        game := admin.server.Room(pathSegments(r)[1])
+        if game == nil {
+            writeError(w, http.StatusNotFound, "no such room")
+            return
+        }
+        handler(w, r, game)
+    }
+}
+
+func (admin *Admin) withClient(handler clientHandler) http.HandlerFunc {
+    return admin.withRoom(func(w http.ResponseWriter, r *http.Request, game *server.Game) {
+        // /rooms/{room}/clients/{id}/...
+        id, err := strconv.Atoi(pathSegments(r)[3])
+        if err != nil {
+            writeError(w, http.StatusBadRequest, "client id should be an integer")
+            return
+        }
+        handler(w, r, game, id)
+    })
+}
+
+func (admin *Admin) listRooms(w http.ResponseWriter, r *http.Request) {
+    if r.Method != "GET" {
+        writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+        return
+    }
+    rooms := make([]server.RoomInfo, 0)
+    for _, game := range admin.server.Rooms() {
+        info, err := game.Info()
+        if err == nil {
+            rooms = append(rooms, info)
+        }
+    }
+    writeJSON(w, http.StatusOK, rooms)
+}
+
+func (admin *Admin) showTournament(w http.ResponseWriter, r *http.Request) {
+    if r.Method != "GET" {
+        writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+        return
+    }
+    info, ok := admin.server.Tournament()
+    if !ok {
+        writeError(w, http.StatusNotFound, "no tournament is being played")
+        return
+    }
+    writeJSON(w, http.StatusOK, info)
+}
+
+func (admin *Admin) showRoom(w http.ResponseWriter, r *http.Request, game *server.Game) {
+    info, err := game.Info()
+    reply(w, info, err)
+}
+
+func (admin *Admin) resetRoom(w http.ResponseWriter, r *http.Request, game *server.Game) {
+    reply(w, nil, game.ResetRound())
+}
+
+func (admin *Admin) shutdownRoom(w http.ResponseWriter, r *http.Request, game *server.Game) {
+    game.Shutdown()
+    reply(w, nil, nil)
+}
+
+func (admin *Admin) revokeMaster(w http.ResponseWriter, r *http.Request, game *server.Game) {
+    reply(w, nil, game.RevokeMaster())
+}
+
+func (admin *Admin) kick(w http.ResponseWriter, r *http.Request, game *server.Game, id int) {
+    reply(w, nil, game.Kick(id, r.FormValue("reason")))
+}
+
+func (admin *Admin) rename(w http.ResponseWriter, r *http.Request, game *server.Game, id int) {
+    name := r.FormValue("name")
+    if name == "" {
+        writeError(w, http.StatusBadRequest, "name is required")
+        return
+    }
+    reply(w, nil, game.Rename(id, name))
+}
+
+func (admin *Admin) grantMaster(w http.ResponseWriter, r *http.Request, game *server.Game, id int) {
+    reply(w, nil, game.GrantMaster(id))
+}
+
+// writes either the result or the error of an action
+func reply(w http.ResponseWriter, result interface{}, err error) {
+    switch err {
+    case nil:
+        if result == nil {
+            result = map[string]string{"status": "ok"}
+        }
+        writeJSON(w, http.StatusOK, result)
+    case server.ErrNoSuchClient, server.ErrRoomClosed:
+        writeError(w, http.StatusNotFound, err.Error())
+    default:
+        writeError(w, http.StatusConflict, err.Error())
+    }
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+    writeJSON(w, status, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(status)
+    json.NewEncoder(w).Encode(data)
+}
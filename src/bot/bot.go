@@ -0,0 +1,258 @@
+package bot
+
+
+import (
+    "bufio"
+    "events"
+    "fmt"
+    "math/rand"
+    "net"
+    "protocol"
+    "settings"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// simulated players and a master driving rounds, to see how the server
+// copes with a full online tournament
+
+type Config struct {
+    Addr string
+    Players int
+    Rounds int
+    RoundSeconds int
+    // players press at a random moment this long after the countdown starts
+    PressDelayMin time.Duration
+    PressDelayMax time.Duration
+    // chance a player says hello in the chat after joining
+    ChatProbability float64
+    // chance the master accepts an answer
+    AcceptProbability float64
+    // pause between two players joining
+    JoinInterval time.Duration
+    // a press not acknowledged within that is counted as an error
+    AckTimeout time.Duration
+}
+
+func DefaultConfig() Config {
+    return Config{
+        Addr: net.JoinHostPort(settings.SERVER, strconv.Itoa(settings.PORT)),
+        Players: 10,
+        Rounds: 5,
+        RoundSeconds: 5,
+        PressDelayMin: 100 * time.Millisecond,
+        PressDelayMax: 2 * time.Second,
+        ChatProbability: 0.2,
+        AcceptProbability: 0.5,
+        JoinInterval: 10 * time.Millisecond,
+        AckTimeout: 5 * time.Second,
+    }
+}
+
+// a connection of a single bot
+type bot struct {
+    name string
+    conn net.Conn
+    stats *Stats
+    mu sync.Mutex
+    // when the unacknowledged press was sent, zero if none
+    pressSent time.Time
+    // delayed presses and answers may fire after the run is over
+    closed bool
+}
+
+func dial(addr string, name string, stats *Stats) (*bot, error) {
+    conn, err := net.Dial("tcp", addr)
+    if err != nil {
+        stats.Error("connect")
+        return nil, err
+    }
+    b := &bot{name: name, conn: conn, stats: stats}
+    b.send(protocol.Subscribe + " on")
+    b.send(":rename " + name)
+    return b, nil
+}
+
+func (b *bot) send(line string) {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.closed {
+        return
+    }
+    if _, err := fmt.Fprintf(b.conn, "%s\n", line); err != nil {
+        b.stats.Error("write")
+    }
+}
+
+func (b *bot) press() {
+    b.mu.Lock()
+    if b.closed || !b.pressSent.IsZero() {
+        // still waiting for the previous one
+        b.mu.Unlock()
+        return
+    }
+    b.pressSent = time.Now()
+    b.mu.Unlock()
+    b.send("")
+    b.stats.Press()
+    time.AfterFunc(b.stats.ackTimeout, func() {
+        b.mu.Lock()
+        defer b.mu.Unlock()
+        if !b.closed && !b.pressSent.IsZero() && time.Since(b.pressSent) >= b.stats.ackTimeout {
+            b.pressSent = time.Time{}
+            b.stats.Error("press not acknowledged")
+        }
+    })
+}
+
+// the server has reacted to our press one way or another
+func (b *bot) acknowledged() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if b.pressSent.IsZero() {
+        return
+    }
+    b.stats.Latency(time.Since(b.pressSent))
+    b.pressSent = time.Time{}
+}
+
+// reads lines, answers pings and passes events and text to the handlers
+func (b *bot) read(onEvent func(events.Event), onText func(string)) {
+    reader := bufio.NewReader(b.conn)
+    for {
+        line, err := reader.ReadString(settings.EOL)
+        if err != nil {
+            return
+        }
+        if strings.HasPrefix(line, protocol.Ping) {
+            b.mu.Lock()
+            fmt.Fprintf(b.conn, "%s%s", protocol.Pong, strings.TrimPrefix(line, protocol.Ping))
+            b.mu.Unlock()
+            continue
+        }
+        if e, ok := protocol.DecodeEvent(line); ok {
+            if e.Actor == b.name {
+                switch e.Kind {
+                case events.Press, events.LatePress, events.FalseStart:
+                    b.acknowledged()
+                case events.Refused:
+                    if e.Payload == "command.unknown" {
+                        b.stats.Error("unknown command")
+                    } else {
+                        b.acknowledged()
+                    }
+                }
+            }
+            onEvent(e)
+            continue
+        }
+        if protocol.IsControl(line) {
+            continue
+        }
+        onText(strings.TrimSpace(line))
+    }
+}
+
+func (b *bot) close() {
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    b.closed = true
+    b.conn.Close()
+}
+
+func randomDelay(min time.Duration, max time.Duration) time.Duration {
+    if max <= min {
+        return min
+    }
+    return min + time.Duration(rand.Int63n(int64(max - min)))
+}
+
+func runPlayer(cfg Config, b *bot) {
+    if rand.Float64() < cfg.ChatProbability {
+        b.send(fmt.Sprintf("Hello from %s!", b.name))
+    }
+    b.read(func(e events.Event) {
+        switch e.Kind {
+        case events.TimerStart:
+            time.AfterFunc(randomDelay(cfg.PressDelayMin, cfg.PressDelayMax), b.press)
+        case events.Press:
+            if e.Actor == b.name {
+                time.AfterFunc(randomDelay(0, 500 * time.Millisecond), func() {
+                    b.send(fmt.Sprintf("%s thinks it's %d", b.name, rand.Intn(100)))
+                })
+            }
+        }
+    }, func(string) {})
+}
+
+// joins everyone, plays the rounds and disconnects
+func Run(cfg Config) *Stats {
+    stats := newStats(cfg.AckTimeout)
+    master, err := dial(cfg.Addr, "bot-master", stats)
+    if err != nil {
+        return stats
+    }
+    defer master.close()
+    roundEvents := make(chan events.Event, 64)
+    go master.read(func(e events.Event) {
+        select {
+        case roundEvents <- e:
+        default:
+            // only the round flow matters to the master, chatter may be lost
+        }
+    }, func(string) {})
+    master.send(":master")
+
+    var players []*bot
+    for i := 1; i <= cfg.Players; i++ {
+        b, err := dial(cfg.Addr, fmt.Sprintf("bot-%d", i), stats)
+        if err != nil {
+            continue
+        }
+        players = append(players, b)
+        go runPlayer(cfg, b)
+        time.Sleep(cfg.JoinInterval)
+    }
+    defer func() {
+        for _, b := range players {
+            b.close()
+        }
+    }()
+
+    master.send(":game")
+    for round := 1; round <= cfg.Rounds; round++ {
+        master.send(fmt.Sprintf(":time %d", cfg.RoundSeconds))
+        playRound(cfg, master, roundEvents)
+        stats.Round()
+    }
+    // presses are silently ignored in chat mode, let the late ones in first
+    time.Sleep(cfg.PressDelayMax + 200 * time.Millisecond)
+    master.send(":chat")
+    return stats
+}
+
+// judges answers until the round is over
+func playRound(cfg Config, master *bot, roundEvents chan events.Event) {
+    deadline := time.After(time.Duration(cfg.RoundSeconds) * time.Second + cfg.AckTimeout)
+    for {
+        select {
+        case e := <-roundEvents:
+            switch e.Kind {
+            case events.Answer:
+                if rand.Float64() < cfg.AcceptProbability {
+                    master.send(":accept")
+                    return
+                }
+                master.send(":reject")
+            case events.Timeout:
+                return
+            }
+        case <-deadline:
+            master.stats.Error("round did not end")
+            master.send(":reset")
+            return
+        }
+    }
+}
@@ -0,0 +1,95 @@
+package bot
+
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// what the bots have seen, safe for concurrent use
+type Stats struct {
+    mu sync.Mutex
+    ackTimeout time.Duration
+    rounds int
+    presses int
+    latencies []time.Duration
+    errors map[string]int
+}
+
+func newStats(ackTimeout time.Duration) *Stats {
+    return &Stats{ackTimeout: ackTimeout, errors: make(map[string]int)}
+}
+
+func (s *Stats) Round() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.rounds++
+}
+
+func (s *Stats) Press() {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.presses++
+}
+
+func (s *Stats) Latency(d time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.latencies = append(s.latencies, d)
+}
+
+func (s *Stats) Error(kind string) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.errors[kind]++
+}
+
+func (s *Stats) Errors() int {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    total := 0
+    for _, n := range s.errors {
+        total += n
+    }
+    return total
+}
+
+// press-to-acknowledgement latency at the given percentile (0..100)
+func (s *Stats) Percentile(p float64) time.Duration {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    if len(s.latencies) == 0 {
+        return 0
+    }
+    sorted := append([]time.Duration(nil), s.latencies...)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+    idx := int(p / 100 * float64(len(sorted) - 1) + 0.5)
+    return sorted[idx]
+}
+
+func (s *Stats) String() string {
+    var b strings.Builder
+    s.mu.Lock()
+    fmt.Fprintf(&b, "rounds: %d, presses: %d, acknowledged: %d\n",
+                s.rounds, s.presses, len(s.latencies))
+    s.mu.Unlock()
+    fmt.Fprintf(&b, "latency p50: %s, p90: %s, p99: %s, max: %s\n",
+                s.Percentile(50), s.Percentile(90), s.Percentile(99), s.Percentile(100))
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    kinds := make([]string, 0, len(s.errors))
+    for kind := range s.errors {
+        kinds = append(kinds, kind)
+    }
+    sort.Strings(kinds)
+    if len(kinds) == 0 {
+        b.WriteString("no errors\n")
+    }
+    for _, kind := range kinds {
+        fmt.Fprintf(&b, "error %s: %d\n", kind, s.errors[kind])
+    }
+    return b.String()
+}
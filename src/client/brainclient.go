@@ -1,33 +1,88 @@
 package client
 
 
-import ("net" 
+import ("net"
+        "events"
         "fmt"
         "bufio"
         "os"
+        "protocol"
+        "strconv"
+        "strings"
         "time"
         "utils")
 
 
-func StartClient(server string, port int) {
+type Options struct {
+    // plain line mode even if running in a terminal
+    Plain bool
+    // master console: claims the master role, shows answers, judging hotkeys
+    Master bool
+    // reconnect when the connection is lost instead of exiting
+    Reconnect bool
+    // keep what is typed while offline and send it once reconnected,
+    // otherwise it's thrown away
+    HoldInput bool
+    // how cues sound, see cues.go
+    Sound string
+}
+
+func StartClient(server string, port int, opts Options) {
     fmt.Println("Launching Brain Client...")
-    conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", server, port))
-    utils.ProcError(err)
-    chReceive := make(chan string)
+    l := newLink(net.JoinHostPort(server, strconv.Itoa(port)), opts)
+    if opts.Master {
+        l.firstGreeting = append(l.firstGreeting, ":master")
+    }
+    if !opts.Plain && isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+        utils.ProcError(runTUI(l, opts.Master, opts.Sound))
+        return
+    }
+    // for the cues
+    l.greeting = append(l.greeting, protocol.Subscribe + " on")
+    l.start()
+    runLineMode(l, &cuePlayer{command: opts.Sound, out: os.Stdout})
+}
+
+func rosterLine(players []protocol.Player) string {
+    var names []string
+    for _, player := range players {
+        name := player.Name
+        if player.Role == "master" {
+            name = "(master) " + name
+        }
+        names = append(names, fmt.Sprintf("%s [%d]", name, player.Score))
+    }
+    return "*** " + strings.Join(names, ", ")
+}
+
+// prints whatever comes from the server, sends whatever is typed
+func runLineMode(l *link, cues *cuePlayer) {
     chSend := make(chan string)
     errCh := make(chan error)
     // shellInvite := ">"
-    // read data goroutine
-    go utils.ReadData(bufio.NewReader(conn), chReceive, errCh)
     go utils.ReadData(bufio.NewReader(os.Stdin), chSend, errCh)
     ticker := time.Tick(time.Second)
     for {
         select {
-        case data := <-chReceive:
+        case data := <-l.lines:
+            if e, ok := protocol.DecodeEvent(data); ok && e.Kind == events.Cue {
+                cues.play(e.Payload)
+            }
+            if players, ok := protocol.DecodeRoster(data); ok {
+                // what ":who" gets once subscribed
+                fmt.Println(rosterLine(players))
+            }
+            if protocol.IsControl(data) {
+                continue
+            }
             fmt.Println(data)
+        case state := <-l.states:
+            fmt.Printf("*** %s\n", state)
         case data := <-chSend:
             // make sure plain '\n' can be sent
-            fmt.Fprintf(conn, data)
+            l.Send(strings.TrimSuffix(data, "\n"))
+        case err := <-l.errors:
+            utils.ProcError(err)
         case err := <-errCh:
             utils.ProcError(err)
         case <- ticker:
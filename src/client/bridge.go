@@ -0,0 +1,189 @@
+package client
+
+
+import (
+    "fmt"
+    "io"
+    "net"
+    "os"
+    "protocol"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// a bridge lets a box of hardware buttons play: it reads button ids, one
+// a line, from a serial device or any other line source and presses for
+// the team the button belongs to. Every team has a connection of its own,
+// so one machine at a table plays for all the teams sitting there. Presses
+// are dated when read and sent as ":press <ms>", the way to the server
+// doesn't count; the server takes the dates of bridges knowing its bridge
+// token only. Whatever follows the id on a line is ignored
+
+// a button repeating itself sooner than that is bouncing
+const bounceTime = 50 * time.Millisecond
+
+type Bridge struct {
+    // button id -> team
+    teams map[string]string
+    // team -> its connection
+    links map[string]*link
+    // when a button has been pressed last
+    last map[string]time.Time
+    out io.Writer
+    done chan struct{}
+}
+
+// "1=Alpha,2=Beta", several buttons may belong to a team
+func ParseTeams(spec string) (map[string]string, error) {
+    teams := make(map[string]string)
+    for _, entry := range strings.Split(spec, ",") {
+        if strings.TrimSpace(entry) == "" {
+            continue
+        }
+        kv := strings.SplitN(entry, "=", 2)
+        if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" || strings.TrimSpace(kv[1]) == "" {
+            return nil, fmt.Errorf("'%s' should be button=team", entry)
+        }
+        id := strings.TrimSpace(kv[0])
+        if _, ok := teams[id]; ok {
+            return nil, fmt.Errorf("button %s is given twice", id)
+        }
+        teams[id] = strings.TrimSpace(kv[1])
+    }
+    if len(teams) == 0 {
+        return nil, fmt.Errorf("no buttons given")
+    }
+    return teams, nil
+}
+
+// connects the teams, they come into the room unless it's empty; token
+// is the server's bridge token, without it presses are not dated; what
+// the server tells the first team and how the connections go is written
+// to out
+func NewBridge(server string, port int, teams map[string]string, room string, token string,
+               out io.Writer) *Bridge {
+    addr := net.JoinHostPort(server, strconv.Itoa(port))
+    b := &Bridge{teams: teams,
+                 links: make(map[string]*link),
+                 last: make(map[string]time.Time),
+                 out: out,
+                 done: make(chan struct{})}
+    var names []string
+    for _, team := range teams {
+        if _, ok := b.links[team]; ok {
+            continue
+        }
+        l := newLink(addr, Options{Reconnect: true})
+        if token != "" {
+            l.greeting = append(l.greeting, protocol.Bridge + " " + token)
+        }
+        l.firstGreeting = append(l.firstGreeting, ":rename " + team)
+        if room != "" {
+            l.firstGreeting = append(l.firstGreeting, ":join " + room)
+        }
+        b.links[team] = l
+        names = append(names, team)
+    }
+    sort.Strings(names)
+    for i, team := range names {
+        go b.watch(team, b.links[team], i == 0)
+        b.links[team].start()
+    }
+    return b
+}
+
+// the server talks to every team the same, one of them is enough to echo
+func (b *Bridge) watch(team string, l *link, echo bool) {
+    for {
+        select {
+        case line := <-l.lines:
+            if echo && !protocol.IsControl(line) {
+                fmt.Fprint(b.out, line)
+            }
+        case state := <-l.states:
+            fmt.Fprintf(b.out, "*** %s: %s\n", team, state)
+        case <-b.done:
+            return
+        }
+    }
+}
+
+// presses buttons as they come until the input is over, a press is dated
+// by the first byte of its line: a slow box may take a while to send the
+// rest of it
+func (b *Bridge) Run(input io.Reader) error {
+    buf := make([]byte, 256)
+    var line []byte
+    var stamp time.Time
+    for {
+        n, err := input.Read(buf)
+        now := time.Now()
+        for _, c := range buf[:n] {
+            if len(line) == 0 && stamp.IsZero() {
+                stamp = now
+            }
+            if c != '\n' {
+                line = append(line, c)
+                continue
+            }
+            b.press(string(line), stamp)
+            line = line[:0]
+            stamp = time.Time{}
+        }
+        if err == io.EOF {
+            if len(line) > 0 {
+                b.press(string(line), stamp)
+            }
+            return nil
+        }
+        if err != nil {
+            return err
+        }
+    }
+}
+
+func (b *Bridge) press(line string, stamp time.Time) {
+    words := strings.Fields(line)
+    if len(words) == 0 {
+        return
+    }
+    team, ok := b.teams[words[0]]
+    if !ok {
+        fmt.Fprintf(b.out, "*** unknown button %s\n", words[0])
+        return
+    }
+    if stamp.Sub(b.last[words[0]]) < bounceTime {
+        return
+    }
+    b.last[words[0]] = stamp
+    b.links[team].Send(fmt.Sprintf("%s %d", protocol.Press, time.Since(stamp).Milliseconds()))
+}
+
+// disconnects the teams
+func (b *Bridge) Close() {
+    close(b.done)
+    for _, l := range b.links {
+        l.close()
+    }
+}
+
+// "-" is the standard input, a terminal device is set up as a serial
+// port: raw, 8N1 at the given baud rate, 0 keeps the rate
+func OpenInput(path string, baud int) (io.ReadCloser, error) {
+    if path == "-" {
+        return os.Stdin, nil
+    }
+    f, err := openSerial(path)
+    if err != nil {
+        return nil, err
+    }
+    if isTerminal(f) {
+        if err := setSerial(f, baud); err != nil {
+            f.Close()
+            return nil, fmt.Errorf("cannot set up %s: %s", path, err)
+        }
+    }
+    return f, nil
+}
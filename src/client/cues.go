@@ -0,0 +1,95 @@
+package client
+
+
+import (
+    "events"
+    "fmt"
+    "io"
+    "os/exec"
+    "strings"
+    "time"
+)
+
+// plays the server's cues: rings the terminal bell or runs the sound
+// command given with the cue name in place of "%s", e.g.
+// "paplay /usr/share/brain/%s.wav", or as the last argument if there is
+// no "%s"; "off" keeps quiet. The command is run without a shell and only
+// the cues we know of are played, whatever else the server sends is not
+// trusted to be part of a command line
+
+var knownCues = map[string]bool{
+    events.CueStart: true,
+    events.CueWarning: true,
+    events.CuePress: true,
+    events.CueFalseStart: true,
+    events.CueTimeout: true,
+}
+
+// how long the name of the one who has pressed flashes
+const flashTime = 3 * time.Second
+
+type cuePlayer struct {
+    command string
+    out io.Writer
+}
+
+func (p *cuePlayer) play(name string) {
+    if !knownCues[name] {
+        return
+    }
+    switch p.command {
+    case "off":
+        return
+    case "":
+        fmt.Fprint(p.out, "\a")
+        return
+    }
+    args := p.program(name)
+    cmd := exec.Command(args[0], args[1:]...)
+    if err := cmd.Start(); err != nil {
+        // no sound is better than no game
+        fmt.Fprint(p.out, "\a")
+        return
+    }
+    go cmd.Wait()
+}
+
+// the command line with the cue in it
+func (p *cuePlayer) program(name string) []string {
+    words := strings.Fields(p.command)
+    named := false
+    for i, word := range words {
+        if strings.Contains(word, "%s") {
+            words[i] = strings.Replace(word, "%s", name, -1)
+            named = true
+        }
+    }
+    if !named {
+        words = append(words, name)
+    }
+    return words
+}
+
+// a name flashing in the players list
+type flash struct {
+    name string
+    // terminal color of the flash
+    color string
+    until time.Time
+}
+
+func flashFor(e events.Event) (flash, bool) {
+    switch e.Payload {
+    case events.CuePress:
+        return flash{e.Target, "\x1b[1;7m", time.Now().Add(flashTime)}, true
+    case events.CueFalseStart:
+        return flash{e.Target, "\x1b[1;33;7m", time.Now().Add(flashTime)}, true
+    }
+    return flash{}, false
+}
+
+// true while the name is lit, it goes on and off a few times a second
+func (f flash) lit(name string) bool {
+    left := time.Until(f.until)
+    return name == f.name && left > 0 && left / (250 * time.Millisecond) % 2 == 0
+}
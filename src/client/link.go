@@ -0,0 +1,188 @@
+package client
+
+
+import (
+    "bufio"
+    "fmt"
+    "net"
+    "protocol"
+    "settings"
+    "strings"
+    "sync"
+    "time"
+)
+
+// link is a connection to the server surviving network failures: it
+// reconnects with exponential backoff, resumes the player's session and
+// holds (or discards) what is typed while offline
+
+// at most that many lines are held while offline
+const maxPending = 100
+
+type link struct {
+    addr string
+    opts Options
+    // sent after every connect, e.g. ":events on"
+    greeting []string
+    // sent after the first connect only
+    firstGreeting []string
+    // lines from the server, pings and session tokens are handled here
+    lines chan string
+    // connection state changes to be shown to the user
+    states chan string
+    // the link is down for good, reconnecting is off
+    errors chan error
+
+    mu sync.Mutex
+    // nil while offline
+    conn net.Conn
+    token string
+    pending []string
+    // closed for good, no more reconnecting
+    closed bool
+}
+
+func newLink(addr string, opts Options) *link {
+    return &link{addr: addr,
+                 opts: opts,
+                 lines: make(chan string),
+                 states: make(chan string, 16),
+                 errors: make(chan error, 1)}
+}
+
+func (l *link) start() {
+    go l.run()
+}
+
+func (l *link) state(msg string) {
+    select {
+    case l.states <- msg:
+    default:
+        // nobody is watching that closely
+    }
+}
+
+func (l *link) run() {
+    delay := settings.ReconnectMin
+    first := true
+    for {
+        conn, err := net.Dial("tcp", l.addr)
+        if l.isClosed() {
+            if err == nil {
+                conn.Close()
+            }
+            return
+        }
+        if err != nil {
+            if !l.opts.Reconnect {
+                l.errors <- err
+                return
+            }
+            l.state(fmt.Sprintf("cannot connect: %s, retrying in %s", err, delay))
+            time.Sleep(delay)
+            delay *= 2
+            if delay > settings.ReconnectMax {
+                delay = settings.ReconnectMax
+            }
+            continue
+        }
+        delay = settings.ReconnectMin
+        l.connected(conn, first)
+        first = false
+        err = l.read(conn)
+        l.mu.Lock()
+        l.conn = nil
+        l.mu.Unlock()
+        conn.Close()
+        if l.isClosed() {
+            return
+        }
+        if !l.opts.Reconnect {
+            l.errors <- err
+            return
+        }
+        l.state(fmt.Sprintf("connection lost: %s, reconnecting", err))
+    }
+}
+
+func (l *link) connected(conn net.Conn, first bool) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    var hello []string
+    if l.token != "" {
+        // resume first, so that everything else is done as the old player
+        hello = append(hello, fmt.Sprintf("%s %s", protocol.ResumeSession, l.token))
+    }
+    hello = append(hello, l.greeting...)
+    if first {
+        hello = append(hello, l.firstGreeting...)
+    }
+    hello = append(hello, l.pending...)
+    l.pending = nil
+    for _, line := range hello {
+        fmt.Fprintf(conn, "%s\n", line)
+    }
+    l.conn = conn
+    l.state(fmt.Sprintf("connected to %s", l.addr))
+}
+
+func (l *link) isClosed() bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    return l.closed
+}
+
+// drops the connection and stops reconnecting
+func (l *link) close() {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    l.closed = true
+    if l.conn != nil {
+        l.conn.Close()
+    }
+}
+
+// passes lines on until the connection breaks
+func (l *link) read(conn net.Conn) error {
+    reader := bufio.NewReader(conn)
+    for {
+        line, err := reader.ReadString(settings.EOL)
+        if err != nil {
+            return err
+        }
+        if strings.HasPrefix(line, protocol.Ping) {
+            // answer with the same token so the server can measure rtt
+            fmt.Fprintf(conn, "%s%s", protocol.Pong, strings.TrimPrefix(line, protocol.Ping))
+            continue
+        }
+        if strings.HasPrefix(line, protocol.Session + " ") {
+            l.mu.Lock()
+            l.token = strings.TrimSpace(strings.TrimPrefix(line, protocol.Session))
+            l.mu.Unlock()
+            continue
+        }
+        l.lines <- line
+    }
+}
+
+// sends a line without the end of line, holds or drops it while offline
+func (l *link) Send(line string) {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+    if l.conn != nil {
+        if _, err := fmt.Fprintf(l.conn, "%s\n", line); err == nil {
+            return
+        }
+    }
+    if line == "" || !l.opts.HoldInput {
+        // a button press is meaningless once we are back
+        l.state("offline, input discarded")
+        return
+    }
+    if len(l.pending) >= maxPending {
+        l.state("offline, too much input held, discarded")
+        return
+    }
+    l.pending = append(l.pending, line)
+    l.state(fmt.Sprintf("offline, %d lines held until reconnected", len(l.pending)))
+}
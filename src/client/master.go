@@ -0,0 +1,138 @@
+package client
+
+
+import (
+    "encoding/json"
+    "events"
+    "fmt"
+    "pack"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// master console additions to the full screen client
+
+type press struct {
+    name string
+    kind events.Kind
+    at time.Time
+}
+
+func decodeQuestion(data string) *pack.Question {
+    q := &pack.Question{}
+    if json.Unmarshal([]byte(data), q) != nil {
+        return nil
+    }
+    return q
+}
+
+// single key master actions, returns false if key is not one of them
+func (ui *tui) masterKey(key rune) bool {
+    switch key {
+    case keyCtrlT:
+        ui.send(":time")
+    case keyCtrlA:
+        ui.send(":accept")
+    case keyCtrlR:
+        ui.send(":reject")
+    case keyCtrlN:
+        ui.send(":next")
+    case keyCtrlP:
+        if ui.paused {
+            ui.send(":resume")
+        } else {
+            ui.send(":pause")
+        }
+    default:
+        return false
+    }
+    return true
+}
+
+// splits text into lines of at most width runes, on spaces where possible
+func wrap(text string, width int) []string {
+    var lines []string
+    for _, paragraph := range strings.Split(text, "\n") {
+        line := ""
+        for _, word := range strings.Fields(paragraph) {
+            for utf8.RuneCountInString(word) > width {
+                if line != "" {
+                    lines = append(lines, line)
+                    line = ""
+                }
+                lines = append(lines, string([]rune(word)[:width]))
+                word = string([]rune(word)[width:])
+            }
+            if line == "" {
+                line = word
+            } else if utf8.RuneCountInString(line) + 1 + utf8.RuneCountInString(word) <= width {
+                line += " " + word
+            } else {
+                lines = append(lines, line)
+                line = word
+            }
+        }
+        lines = append(lines, line)
+    }
+    return lines
+}
+
+// the current question with its answer, only the master sees it
+func (ui *tui) questionPane(width int, height int) []string {
+    lines := []string{"\x1b[7m" + fit(" Question (private)", width) + "\x1b[0m"}
+    q := ui.question
+    if q == nil {
+        lines = append(lines, fit(" no question yet, load a pack with :pack <file>", width))
+    } else {
+        fields := []struct{ title, text string }{
+            {fmt.Sprintf("%d.", q.Number), q.Text},
+            {"Answer:", q.Answer},
+            {"Accept:", q.Accept},
+            {"Comment:", q.Comment},
+            {"Source:", q.Source},
+            {"Author:", q.Author},
+        }
+        for _, field := range fields {
+            if field.text == "" {
+                continue
+            }
+            for i, line := range wrap(field.title + " " + field.text, width - 1) {
+                if i == 0 && field.title == "Answer:" {
+                    lines = append(lines, "\x1b[1m " + fit(line, width - 1) + "\x1b[0m")
+                } else {
+                    lines = append(lines, " " + fit(line, width - 1))
+                }
+            }
+        }
+    }
+    if len(lines) > height {
+        lines = lines[:height]
+    }
+    for len(lines) < height {
+        lines = append(lines, fit("", width))
+    }
+    return lines
+}
+
+// who pressed in what order, counted from the countdown start
+func (ui *tui) pressLines() []string {
+    lines := []string{fit(" Presses", sideWidth)}
+    for i, p := range ui.presses {
+        mark := ""
+        switch p.kind {
+        case events.FalseStart:
+            mark = " false"
+        case events.LatePress:
+            mark = " late"
+        }
+        offset := ""
+        if !ui.timerStarted.IsZero() && p.kind != events.FalseStart {
+            offset = fmt.Sprintf("+%.3fs", p.at.Sub(ui.timerStarted).Seconds())
+        } else {
+            offset = p.at.Format("15:04:05.000")
+        }
+        lines = append(lines, fit(fmt.Sprintf("%2d %-12s %s%s", i + 1, p.name, offset, mark), sideWidth))
+    }
+    return lines
+}
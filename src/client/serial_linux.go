@@ -0,0 +1,56 @@
+//go:build linux
+// +build linux
+
+package client
+
+
+import (
+    "fmt"
+    "os"
+    "syscall"
+)
+
+// the speed bits of c_cflag
+const cbaud = 0x100f
+
+var bauds = map[int]uint32{
+    1200: syscall.B1200,
+    2400: syscall.B2400,
+    4800: syscall.B4800,
+    9600: syscall.B9600,
+    19200: syscall.B19200,
+    38400: syscall.B38400,
+    57600: syscall.B57600,
+    115200: syscall.B115200,
+}
+
+func openSerial(path string) (*os.File, error) {
+    // a button box should not become our controlling terminal
+    return os.OpenFile(path, os.O_RDONLY | syscall.O_NOCTTY, 0)
+}
+
+// raw 8N1 at the given speed, 0 leaves the speed as it is
+func setSerial(f *os.File, baud int) error {
+    termios, err := getTermios(f.Fd())
+    if err != nil {
+        return err
+    }
+    raw := *termios
+    raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+                  syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+    raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+    raw.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.CSTOPB
+    raw.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL
+    raw.Cc[syscall.VMIN] = 1
+    raw.Cc[syscall.VTIME] = 0
+    if baud != 0 {
+        speed, ok := bauds[baud]
+        if !ok {
+            return fmt.Errorf("unsupported baud rate %d", baud)
+        }
+        raw.Cflag = raw.Cflag &^ cbaud | speed
+        raw.Ispeed = speed
+        raw.Ospeed = speed
+    }
+    return setTermios(f.Fd(), &raw)
+}
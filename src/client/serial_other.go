@@ -0,0 +1,17 @@
+//go:build !linux
+// +build !linux
+
+package client
+
+
+import "os"
+
+func openSerial(path string) (*os.File, error) {
+    return os.Open(path)
+}
+
+// serial ports are read as they are elsewhere, set them up with stty or
+// the like beforehand
+func setSerial(f *os.File, baud int) error {
+    return nil
+}
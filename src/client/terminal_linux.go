@@ -0,0 +1,70 @@
+//go:build linux
+// +build linux
+
+package client
+
+
+import (
+    "os"
+    "syscall"
+    "unsafe"
+)
+
+func getTermios(fd uintptr) (*syscall.Termios, error) {
+    var termios syscall.Termios
+    _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCGETS,
+                                   uintptr(unsafe.Pointer(&termios)))
+    if errno != 0 {
+        return nil, errno
+    }
+    return &termios, nil
+}
+
+func setTermios(fd uintptr, termios *syscall.Termios) error {
+    _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TCSETS,
+                                   uintptr(unsafe.Pointer(termios)))
+    if errno != 0 {
+        return errno
+    }
+    return nil
+}
+
+func isTerminal(f *os.File) bool {
+    _, err := getTermios(f.Fd())
+    return err == nil
+}
+
+// switches the terminal to raw mode, returns a function restoring it
+func makeRaw(f *os.File) (func(), error) {
+    old, err := getTermios(f.Fd())
+    if err != nil {
+        return nil, err
+    }
+    raw := *old
+    raw.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+                  syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+    raw.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+    raw.Cflag &^= syscall.CSIZE | syscall.PARENB
+    raw.Cflag |= syscall.CS8
+    raw.Cc[syscall.VMIN] = 1
+    raw.Cc[syscall.VTIME] = 0
+    if err := setTermios(f.Fd(), &raw); err != nil {
+        return nil, err
+    }
+    return func() { setTermios(f.Fd(), old) }, nil
+}
+
+func terminalSize(f *os.File) (int, int, error) {
+    var size struct {
+        rows, cols, x, y uint16
+    }
+    _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), syscall.TIOCGWINSZ,
+                                   uintptr(unsafe.Pointer(&size)))
+    if errno != 0 {
+        return 0, 0, errno
+    }
+    return int(size.cols), int(size.rows), nil
+}
+
+// signals telling the terminal has been resized
+var resizeSignals = []os.Signal{syscall.SIGWINCH}
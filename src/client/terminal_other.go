@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package client
+
+
+import (
+    "errors"
+    "os"
+)
+
+// full screen mode is only supported on linux, elsewhere
+// the client always falls back to line mode
+
+var errNoTerminal = errors.New("terminal control is not supported on this platform")
+
+func isTerminal(f *os.File) bool {
+    return false
+}
+
+func makeRaw(f *os.File) (func(), error) {
+    return nil, errNoTerminal
+}
+
+func terminalSize(f *os.File) (int, int, error) {
+    return 0, 0, errNoTerminal
+}
+
+var resizeSignals []os.Signal
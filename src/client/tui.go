@@ -0,0 +1,479 @@
+package client
+
+
+import (
+    "bufio"
+    "events"
+    "fmt"
+    "os"
+    "os/signal"
+    "pack"
+    "protocol"
+    "strconv"
+    "strings"
+    "time"
+    "unicode/utf8"
+)
+
+// full screen client: messages on the left, players and the countdown
+// on the right, input line at the bottom
+
+const (
+    keyCtrlA = 1
+    keyCtrlB = 2
+    keyCtrlC = 3
+    keyCtrlD = 4
+    keyTab = 9
+    keyEnter = 13
+    keyCtrlN = 14
+    keyCtrlP = 16
+    keyCtrlR = 18
+    keyCtrlT = 20
+    keyCtrlU = 21
+    keyEsc = 27
+    keyBackspace = 127
+    keyCtrlH = 8
+)
+
+// what Tab completes, the server knows the rest
+var knownCommands = []string{
+    ":accept", ":ban", ":bans", ":bracket", ":chat", ":comaster", ":events", ":exit",
+    ":falsestart", ":game", ":help", ":history", ":join", ":kick", ":lang",
+    ":master", ":masters", ":match", ":msg", ":mute", ":next", ":pack", ":pause",
+    ":redo", ":reject", ":rename", ":reset", ":resume", ":reveal", ":t", ":team", ":teamchat",
+    ":time", ":tournament", ":unban", ":undo", ":unmute", ":who",
+}
+
+// lines kept in the message pane
+const historySize = 500
+const sideWidth = 30
+
+type tui struct {
+    // master console: hotkeys, question pane and presses list
+    master bool
+    link *link
+    // connection state as the link reports it
+    linkState string
+    out *bufio.Writer
+    width int
+    height int
+    lines []string
+    players []protocol.Player
+    mode string
+    // when the countdown ends, zero if it doesn't run
+    deadline time.Time
+    // countdown is on hold with pausedLeft seconds left
+    paused bool
+    pausedLeft int
+    // master only: the current question and presses since the countdown started
+    question *pack.Question
+    timerStarted time.Time
+    presses []press
+    // who pressed the button and has to answer now
+    pressed string
+    cues *cuePlayer
+    flash flash
+    input []rune
+    // Tab completion state: what the user typed and the next candidate
+    completeBase string
+    completeNext int
+}
+
+func runTUI(l *link, master bool, sound string) error {
+    restore, err := makeRaw(os.Stdin)
+    if err != nil {
+        return err
+    }
+    ui := &tui{master: master, link: l, linkState: "connecting",
+               out: bufio.NewWriter(os.Stdout), mode: "chat"}
+    ui.cues = &cuePlayer{command: sound, out: ui.out}
+    ui.resize()
+    // alternate screen, hidden cursor
+    fmt.Fprint(ui.out, "\x1b[?1049h\x1b[?25l")
+    defer func() {
+        fmt.Fprint(ui.out, "\x1b[?25h\x1b[?1049l")
+        ui.out.Flush()
+        restore()
+    }()
+    l.greeting = append(l.greeting, protocol.Subscribe + " on")
+    l.start()
+
+    errCh := make(chan error, 1)
+    keys := make(chan rune)
+    go readKeys(bufio.NewReader(os.Stdin), keys, errCh)
+    resized := make(chan os.Signal, 1)
+    if len(resizeSignals) > 0 {
+        signal.Notify(resized, resizeSignals...)
+        defer signal.Stop(resized)
+    }
+    ticker := time.NewTicker(200 * time.Millisecond)
+    defer ticker.Stop()
+    for {
+        ui.draw()
+        select {
+        case line := <-l.lines:
+            ui.receive(line)
+        case state := <-l.states:
+            ui.linkState = state
+            ui.addLine("*** " + state)
+        case err := <-l.errors:
+            return err
+        case key := <-keys:
+            if !ui.key(key) {
+                return nil
+            }
+        case <-resized:
+            ui.resize()
+        case err := <-errCh:
+            return err
+        case <-ticker.C:
+        }
+    }
+}
+
+// sends keys pressed, escape sequences (arrows etc.) are skipped
+func readKeys(reader *bufio.Reader, keys chan rune, errCh chan error) {
+    for {
+        r, _, err := reader.ReadRune()
+        if err != nil {
+            errCh <- err
+            return
+        }
+        if r == keyEsc {
+            next, _, err := reader.ReadRune()
+            if err == nil && next == '[' {
+                // CSI: parameters up to a final byte in 0x40-0x7e
+                for {
+                    b, err := reader.ReadByte()
+                    if err != nil || b >= 0x40 && b <= 0x7e {
+                        break
+                    }
+                }
+            }
+            continue
+        }
+        keys <- r
+    }
+}
+
+func (ui *tui) resize() {
+    width, height, err := terminalSize(os.Stdout)
+    if err != nil || width < sideWidth + 20 || height < 10 {
+        width, height = 80, 24
+    }
+    ui.width, ui.height = width, height
+}
+
+func (ui *tui) send(line string) {
+    ui.link.Send(line)
+}
+
+func (ui *tui) addLine(line string) {
+    ui.lines = append(ui.lines, line)
+    if len(ui.lines) > historySize {
+        ui.lines = ui.lines[len(ui.lines) - historySize:]
+    }
+}
+
+func (ui *tui) receive(line string) {
+    if e, ok := protocol.DecodeEvent(line); ok {
+        ui.event(e)
+        return
+    }
+    if players, ok := protocol.DecodeRoster(line); ok {
+        ui.players = players
+        return
+    }
+    if protocol.IsControl(line) {
+        // something newer than us, ignore
+        return
+    }
+    ui.addLine(strings.TrimRight(line, "\r\n"))
+}
+
+func (ui *tui) event(e events.Event) {
+    switch e.Kind {
+    case events.Mode:
+        ui.mode = e.Payload
+        ui.deadline = time.Time{}
+        ui.paused = false
+        ui.pressed = ""
+    case events.TimerStart:
+        seconds, err := strconv.Atoi(e.Payload)
+        if err == nil {
+            // server and client clocks may differ, count from now
+            ui.deadline = time.Now().Add(time.Duration(seconds) * time.Second)
+        }
+        if !ui.paused {
+            // a new countdown rather than a resumed one
+            ui.timerStarted = e.Time
+            ui.presses = nil
+        }
+        ui.paused = false
+        ui.pressed = ""
+    case events.Pause:
+        ui.paused = true
+        ui.pausedLeft, _ = strconv.Atoi(e.Payload)
+        ui.deadline = time.Time{}
+    case events.Timeout:
+        ui.deadline = time.Time{}
+    case events.Press:
+        ui.pressed = e.Actor
+    case events.Answer:
+        ui.pressed = ""
+    case events.Question:
+        ui.presses = nil
+        ui.pressed = ""
+    case events.QuestionInfo:
+        ui.question = decodeQuestion(e.Payload)
+    case events.Cue:
+        ui.cues.play(e.Payload)
+        if f, ok := flashFor(e); ok {
+            ui.flash = f
+        }
+    }
+    switch e.Kind {
+    case events.Press, events.LatePress, events.FalseStart:
+        ui.presses = append(ui.presses, press{e.Actor, e.Kind, e.Time})
+    }
+    switch e.Kind {
+    case events.Join, events.Leave, events.Rename, events.Master, events.CoMaster,
+         events.Judgement, events.Undo, events.Redo:
+        // player list has changed
+        ui.send(":who")
+    }
+    if e.Kind == events.Judgement && e.Payload == "accept" {
+        ui.deadline = time.Time{}
+    }
+}
+
+// returns false when the user wants to quit
+func (ui *tui) key(key rune) bool {
+    if key != keyTab {
+        ui.completeBase = ""
+    }
+    if ui.master && ui.masterKey(key) {
+        return true
+    }
+    switch key {
+    case keyCtrlC, keyCtrlD:
+        return false
+    case keyCtrlB:
+        // the button
+        ui.send("")
+    case ' ':
+        if len(ui.input) == 0 {
+            // space on an empty line is the button too
+            ui.send("")
+        } else {
+            ui.input = append(ui.input, key)
+        }
+    case keyEnter, '\n':
+        ui.send(string(ui.input))
+        ui.input = ui.input[:0]
+    case keyBackspace, keyCtrlH:
+        if len(ui.input) > 0 {
+            ui.input = ui.input[:len(ui.input) - 1]
+        }
+    case keyCtrlU:
+        ui.input = ui.input[:0]
+    case keyTab:
+        ui.complete()
+    default:
+        if key >= ' ' {
+            ui.input = append(ui.input, key)
+        }
+    }
+    return true
+}
+
+// completes a command name, pressing Tab again cycles through the candidates
+func (ui *tui) complete() {
+    if ui.completeBase == "" {
+        ui.completeBase = string(ui.input)
+        ui.completeNext = 0
+    }
+    base := ui.completeBase
+    if !strings.HasPrefix(base, ":") || strings.Contains(base, " ") {
+        return
+    }
+    var candidates []string
+    for _, cmd := range knownCommands {
+        if strings.HasPrefix(cmd, base) {
+            candidates = append(candidates, cmd)
+        }
+    }
+    if len(candidates) == 0 {
+        return
+    }
+    choice := candidates[ui.completeNext % len(candidates)]
+    ui.completeNext++
+    if len(candidates) == 1 {
+        choice += " "
+    }
+    ui.input = []rune(choice)
+}
+
+// pads or cuts s to exactly width runes
+func fit(s string, width int) string {
+    n := utf8.RuneCountInString(s)
+    if n > width {
+        return string([]rune(s)[:width])
+    }
+    return s + strings.Repeat(" ", width - n)
+}
+
+func (ui *tui) moveTo(row int, col int) {
+    fmt.Fprintf(ui.out, "\x1b[%d;%dH", row, col)
+}
+
+func (ui *tui) secondsLeft() int {
+    if ui.paused {
+        return ui.pausedLeft
+    }
+    if ui.deadline.IsZero() {
+        return -1
+    }
+    left := time.Until(ui.deadline)
+    if left < 0 {
+        return 0
+    }
+    return int(left.Seconds() + 0.999)
+}
+
+func (ui *tui) draw() {
+    mainWidth := ui.width - sideWidth - 1
+    bodyHeight := ui.height - 3
+
+    // header
+    ui.moveTo(1, 1)
+    header := fmt.Sprintf(" Brain Client | %s | %s mode | %d players",
+                          ui.linkState, ui.mode, len(ui.players))
+    fmt.Fprintf(ui.out, "\x1b[7m%s\x1b[0m", fit(header, ui.width))
+
+    // messages, the newest at the bottom, master has the question below them
+    messageHeight := bodyHeight
+    var question []string
+    if ui.master {
+        question = ui.questionPane(mainWidth, bodyHeight * 2 / 5)
+        messageHeight -= len(question)
+    }
+    start := len(ui.lines) - messageHeight
+    for row := 0; row < messageHeight; row++ {
+        ui.moveTo(row + 2, 1)
+        line := ""
+        if start + row >= 0 && start + row < len(ui.lines) {
+            line = ui.lines[start + row]
+        }
+        fmt.Fprintf(ui.out, "%s\x1b[2m│\x1b[0m", fit(line, mainWidth))
+    }
+    for row, line := range question {
+        ui.moveTo(messageHeight + row + 2, 1)
+        fmt.Fprintf(ui.out, "%s\x1b[2m│\x1b[0m", line)
+    }
+
+    // side pane: players on top, countdown below
+    side := ui.sidePane(bodyHeight)
+    for row, line := range side {
+        ui.moveTo(row + 2, mainWidth + 2)
+        fmt.Fprint(ui.out, line)
+    }
+
+    // hint and input
+    ui.moveTo(ui.height - 1, 1)
+    hint := " Ctrl-B/Space: press the button  Tab: complete  Ctrl-C: quit"
+    if ui.master {
+        hint = " ^T time  ^A accept  ^R reject  ^N next question  ^P pause/resume  Tab: complete  ^C quit"
+    }
+    fmt.Fprintf(ui.out, "\x1b[2m%s\x1b[0m", fit(hint, ui.width))
+    ui.moveTo(ui.height, 1)
+    input := string(ui.input)
+    if n := utf8.RuneCountInString(input); n > ui.width - 3 {
+        input = string(ui.input[n - (ui.width - 3):])
+    }
+    fmt.Fprintf(ui.out, "> %s\x1b[7m \x1b[0m", fit(input, ui.width - 3))
+    ui.out.Flush()
+}
+
+func (ui *tui) sidePane(height int) []string {
+    var side []string
+    side = append(side, fit(" Players", sideWidth))
+    for _, player := range ui.players {
+        role := " "
+        if player.Role == "master" {
+            role = "*"
+        } else if player.Role == "co-master" {
+            role = "+"
+        }
+        line := fit(fmt.Sprintf("%s %-20s %4d", role, player.Name, player.Score), sideWidth)
+        if ui.flash.lit(player.Name) {
+            line = ui.flash.color + line + "\x1b[0m"
+        } else if player.Name == ui.pressed {
+            line = "\x1b[7m" + line + "\x1b[0m"
+        }
+        side = append(side, line)
+    }
+    if ui.master {
+        side = append(side, fit("", sideWidth))
+        side = append(side, ui.pressLines()...)
+    }
+    timer := ui.timerLines()
+    for len(side) < height - len(timer) {
+        side = append(side, fit("", sideWidth))
+    }
+    // the countdown matters more than the end of a long list
+    side = append(side[:height - len(timer)], timer...)
+    return side
+}
+
+var bigDigits = [10][5]string{
+    {"███", "█ █", "█ █", "█ █", "███"},
+    {" █ ", "██ ", " █ ", " █ ", "███"},
+    {"███", "  █", "███", "█  ", "███"},
+    {"███", "  █", "███", "  █", "███"},
+    {"█ █", "█ █", "███", "  █", "  █"},
+    {"███", "█  ", "███", "  █", "███"},
+    {"███", "█  ", "███", "█ █", "███"},
+    {"███", "  █", " █ ", " █ ", " █ "},
+    {"███", "█ █", "███", "█ █", "███"},
+    {"███", "█ █", "███", "  █", "███"},
+}
+
+// the countdown in big digits and who is answering
+func (ui *tui) timerLines() []string {
+    lines := make([]string, 7)
+    seconds := ui.secondsLeft()
+    if seconds >= 0 {
+        digits := fmt.Sprintf("%02d", seconds % 100)
+        for row := 0; row < 5; row++ {
+            var b strings.Builder
+            for _, d := range digits {
+                for _, cell := range bigDigits[d - '0'][row] {
+                    // double the width, terminal cells are tall
+                    b.WriteRune(cell)
+                    b.WriteRune(cell)
+                }
+                b.WriteString("  ")
+            }
+            lines[row] = "  " + b.String()
+        }
+    }
+    if ui.pressed != "" {
+        lines[6] = " ► " + ui.pressed
+    }
+    for i := range lines {
+        lines[i] = fit(lines[i], sideWidth)
+    }
+    color := ""
+    if ui.paused {
+        color = "\x1b[33m"
+    } else if seconds == 0 || seconds > 0 && seconds <= 5 {
+        color = "\x1b[31m"
+    }
+    if color != "" {
+        for i := 0; i < 5; i++ {
+            lines[i] = color + lines[i] + "\x1b[0m"
+        }
+    }
+    return lines
+}
@@ -0,0 +1,158 @@
+package display
+
+
+import (
+    "encoding/json"
+    "events"
+    "fmt"
+    "net/http"
+    "server"
+    "settings"
+    "sort"
+    "strings"
+    "time"
+)
+
+// the big screen of a venue: a page for the projector showing the question
+// once it's read out, the countdown, who has pressed and the scores, kept
+// up to date over server-sent events.
+//
+//  GET /display/{room}           the page
+//  GET /display/{room}/events    "state" events with State as json and
+//                                "cue" events with the cue and its target
+//
+// The state is made of what the room tells everyone, never of private
+// events, so the answer is only there once the master has revealed it.
+// No token is asked for, it's read-only and listens on an address of its
+// own, see settings.DISPLAY
+
+type Player struct {
+    Name string `json:"name"`
+    Score int `json:"score"`
+}
+
+type State struct {
+    Room string `json:"room"`
+    // "game" or "chat"
+    Mode string `json:"mode"`
+    Question *server.QuestionInfo `json:"question,omitempty"`
+    TimerRunning bool `json:"timer_running"`
+    TimerPaused bool `json:"timer_paused"`
+    SecondsLeft int `json:"seconds_left"`
+    Pressed string `json:"pressed,omitempty"`
+    // the best first, the master is not among them
+    Players []Player `json:"players"`
+}
+
+func StateOf(info server.RoomInfo) State {
+    state := State{Room: info.Name, Mode: info.Mode, Question: info.Question,
+                   TimerRunning: info.TimerRunning, TimerPaused: info.TimerPaused,
+                   SecondsLeft: info.SecondsLeft, Pressed: info.ButtonPressed,
+                   Players: make([]Player, 0)}
+    for _, client := range info.Clients {
+        if client.Role == "player" {
+            state.Players = append(state.Players, Player{Name: client.Name, Score: client.Score})
+        }
+    }
+    sort.SliceStable(state.Players, func(i, j int) bool {
+        return state.Players[i].Score > state.Players[j].Score
+    })
+    return state
+}
+
+type Display struct {
+    server *server.Server
+}
+
+func New(s *server.Server) *Display {
+    return &Display{server: s}
+}
+
+func (d *Display) ListenAndServe(addr string) error {
+    return http.ListenAndServe(addr, d)
+}
+
+func (d *Display) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+    if segments[0] != "display" || len(segments) < 2 || len(segments) > 3 ||
+       len(segments) == 3 && segments[2] != "events" {
+        http.NotFound(w, r)
+        return
+    }
+    if r.Method != "GET" {
+        http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+        return
+    }
+    if len(segments) == 2 {
+        w.Header().Set("Content-Type", "text/html; charset=utf-8")
+        fmt.Fprint(w, page)
+        return
+    }
+    game := d.server.Room(segments[1])
+    if game == nil {
+        http.Error(w, "no such room", http.StatusNotFound)
+        return
+    }
+    d.stream(w, r, game)
+}
+
+func writeEvent(w http.ResponseWriter, name string, data interface{}) error {
+    encoded, err := json.Marshal(data)
+    if err != nil {
+        return err
+    }
+    _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, encoded)
+    w.(http.Flusher).Flush()
+    return err
+}
+
+// the state again whenever something happens in the room, until the
+// screen goes away or the room is closed
+func (d *Display) stream(w http.ResponseWriter, r *http.Request, game *server.Game) {
+    if _, ok := w.(http.Flusher); !ok {
+        http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+        return
+    }
+    feed := d.server.Events.Subscribe(settings.SendQueueSize)
+    defer feed.Close()
+    w.Header().Set("Content-Type", "text/event-stream")
+    w.Header().Set("Cache-Control", "no-cache")
+    update := func() bool {
+        info, err := game.Info()
+        return err == nil && writeEvent(w, "state", StateOf(info)) == nil
+    }
+    if !update() {
+        return
+    }
+    keepAlive := time.NewTicker(settings.PingInterval)
+    defer keepAlive.Stop()
+    for {
+        select {
+        case e, ok := <-feed.C:
+            if !ok {
+                return
+            }
+            if e.Room != game.Name || e.Private || e.Kind.IsMessage() {
+                continue
+            }
+            if e.Kind == events.Cue {
+                cue := map[string]string{"cue": e.Payload, "target": e.Target}
+                if writeEvent(w, "cue", cue) != nil {
+                    return
+                }
+                continue
+            }
+            if !update() {
+                return
+            }
+        case <-keepAlive.C:
+            // proxies drop quiet connections
+            if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+                return
+            }
+            w.(http.Flusher).Flush()
+        case <-r.Context().Done():
+            return
+        }
+    }
+}
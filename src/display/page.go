@@ -0,0 +1,97 @@
+package display
+
+
+// the projector page, it counts the seconds down itself between states
+const page = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Brain</title>
+<style>
+body { margin: 0; background: #111; color: #eee; font-family: sans-serif; }
+main { display: flex; height: 100vh; }
+#game { flex: 3; padding: 4vh 4vw; display: flex; flex-direction: column; }
+#scores { flex: 1; padding: 4vh 2vw; background: #1c1c1c; font-size: 3vh; }
+#number { font-size: 3vh; color: #999; }
+#question { font-size: 5vh; flex: 1; margin-top: 2vh; }
+#answer { font-size: 4vh; color: #7c7; }
+#timer { font-size: 16vh; text-align: center; }
+#timer.paused { color: #999; }
+#pressed { font-size: 8vh; text-align: center; min-height: 10vh; }
+#pressed.flash { color: #fc3; }
+.player { display: flex; justify-content: space-between; padding: 0.5vh 0; }
+.player.pressed { color: #fc3; }
+</style>
+</head>
+<body>
+<main>
+<section id="game">
+<div id="number"></div>
+<div id="question"></div>
+<div id="answer"></div>
+<div id="timer"></div>
+<div id="pressed"></div>
+</section>
+<section id="scores"></section>
+</main>
+<script>
+var deadline = 0, running = false;
+
+function text(id, value) {
+    document.getElementById(id).textContent = value || "";
+}
+
+function show(state) {
+    var q = state.question;
+    text("number", q ? "#" + q.number : "");
+    text("question", q ? q.text : "");
+    text("answer", q && q.answer ? q.answer + (q.comment ? " (" + q.comment + ")" : "") : "");
+    running = state.timer_running && !state.timer_paused;
+    deadline = Date.now() + state.seconds_left * 1000;
+    document.getElementById("timer").className = state.timer_paused ? "paused" : "";
+    text("timer", state.timer_running || state.timer_paused ? state.seconds_left : "");
+    text("pressed", state.pressed);
+    var scores = document.getElementById("scores");
+    scores.textContent = "";
+    state.players.forEach(function(p) {
+        var row = document.createElement("div");
+        row.className = p.name == state.pressed ? "player pressed" : "player";
+        var name = document.createElement("span"), score = document.createElement("span");
+        name.textContent = p.name;
+        score.textContent = p.score;
+        row.appendChild(name);
+        row.appendChild(score);
+        scores.appendChild(row);
+    });
+}
+
+function cue(c) {
+    var pressed = document.getElementById("pressed");
+    if (c.cue == "press" || c.cue == "falsestart") {
+        pressed.className = "flash";
+        setTimeout(function() { pressed.className = ""; }, 3000);
+    }
+}
+
+setInterval(function() {
+    if (running) {
+        text("timer", Math.max(0, Math.ceil((deadline - Date.now()) / 1000)));
+    }
+}, 200);
+
+function connect() {
+    var source = new EventSource(location.pathname.replace(/\/$/, "") + "/events");
+    source.addEventListener("state", function(e) { show(JSON.parse(e.data)); });
+    source.addEventListener("cue", function(e) { cue(JSON.parse(e.data)); });
+    source.onerror = function() {
+        // the room may not be open yet
+        if (source.readyState == EventSource.CLOSED) {
+            setTimeout(connect, 3000);
+        }
+    };
+}
+connect();
+</script>
+</body>
+</html>
+`
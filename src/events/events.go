@@ -0,0 +1,161 @@
+package events
+
+
+import (
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Kind tells what has happened in a room
+type Kind string
+
+const (
+    // plain text messages, the same ones clients see
+    System Kind = "system"
+    Broadcast Kind = "broadcast"
+    Whisper Kind = "whisper"
+    // game state changes
+    Join Kind = "join"
+    Leave Kind = "leave"
+    Rename Kind = "rename"
+    Master Kind = "master"
+    // target has been appointed a co-master, payload is the rights or "revoked"
+    CoMaster Kind = "comaster"
+    Mode Kind = "mode"
+    TimerStart Kind = "timer"
+    Timeout Kind = "timeout"
+    Press Kind = "press"
+    // a valid press while someone else is answering
+    LatePress Kind = "latepress"
+    FalseStart Kind = "falsestart"
+    Pause Kind = "pause"
+    Answer Kind = "answer"
+    Judgement Kind = "judgement"
+    // a question has been read out, payload is its text
+    Question Kind = "question"
+    // the whole question with answer and comments as json, private
+    QuestionInfo Kind = "questioninfo"
+    // the master has told everyone the answer, payload is it
+    Reveal Kind = "reveal"
+    // a match between two teams, payload is the match info as json
+    MatchStart Kind = "matchstart"
+    MatchEnd Kind = "matchend"
+    // master has taken a command back or done it again, payload is the command
+    Undo Kind = "undo"
+    Redo Kind = "redo"
+    // the server has turned down what actor did, payload is the id of the
+    // message telling why, e.g. "press.paused" or "command.unknown"
+    Refused Kind = "refused"
+    // target can't chat for a while, payload is the reason
+    Mute Kind = "mute"
+    Unmute Kind = "unmute"
+    // a signal for clients to play or show, payload is one of the cues
+    // below, target is the player it is about if any
+    Cue Kind = "cue"
+)
+
+// the cues, the way a club's box signals them
+const (
+    CueStart = "start"
+    // the countdown is about to end, see settings.CueWarning
+    CueWarning = "warning"
+    // target's press has been taken, their lamp lights up
+    CuePress = "press"
+    CueFalseStart = "falsestart"
+    CueTimeout = "timeout"
+)
+
+// true for events carrying a human-readable message
+func (kind Kind) IsMessage() bool {
+    return kind == System || kind == Broadcast || kind == Whisper
+}
+
+type Event struct {
+    Kind Kind `json:"kind"`
+    Room string `json:"room,omitempty"`
+    // who caused the event, plain player name without "(master)"
+    Actor string `json:"actor,omitempty"`
+    // who the event is addressed to, whisper recipient for instance
+    Target string `json:"target,omitempty"`
+    Payload string `json:"payload,omitempty"`
+    Time time.Time `json:"time"`
+    // for masters' eyes only, never shown to players
+    Private bool `json:"private,omitempty"`
+}
+
+func New(kind Kind, room string, actor string, target string, payload string) Event {
+    return Event{Kind: kind, Room: room, Actor: actor, Target: target,
+                 Payload: payload, Time: time.Now()}
+}
+
+// same format the old state channel used: "(broadcast) some text"
+func (e Event) String() string {
+    return fmt.Sprintf("(%s) %s", e.Kind, strings.TrimRight(e.Payload, "\n"))
+}
+
+type Subscription struct {
+    C <-chan Event
+    ch chan Event
+    bus *Bus
+    mu sync.Mutex
+    dropped int
+}
+
+// number of events lost because the subscriber was too slow
+func (sub *Subscription) Dropped() int {
+    sub.mu.Lock()
+    defer sub.mu.Unlock()
+    return sub.dropped
+}
+
+func (sub *Subscription) Close() {
+    sub.bus.unsubscribe(sub)
+}
+
+// Bus fans events out to any number of subscribers. Publishing never
+// blocks: if a subscriber's buffer is full the event is dropped for it
+type Bus struct {
+    mu sync.RWMutex
+    subs map[*Subscription]bool
+}
+
+func NewBus() *Bus {
+    return &Bus{subs: make(map[*Subscription]bool)}
+}
+
+func (bus *Bus) Subscribe(size int) *Subscription {
+    ch := make(chan Event, size)
+    sub := &Subscription{C: ch, ch: ch, bus: bus}
+    bus.mu.Lock()
+    bus.subs[sub] = true
+    bus.mu.Unlock()
+    return sub
+}
+
+func (bus *Bus) unsubscribe(sub *Subscription) {
+    bus.mu.Lock()
+    defer bus.mu.Unlock()
+    if bus.subs[sub] {
+        delete(bus.subs, sub)
+        close(sub.ch)
+    }
+}
+
+func (bus *Bus) Publish(e Event) {
+    if bus == nil {
+        return
+    }
+    bus.mu.RLock()
+    defer bus.mu.RUnlock()
+    for sub := range bus.subs {
+        select {
+        case sub.ch <- e:
+        default:
+            sub.mu.Lock()
+            sub.dropped++
+            sub.mu.Unlock()
+        }
+    }
+}
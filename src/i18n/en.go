@@ -0,0 +1,274 @@
+package i18n
+
+
+// English messages, the ones every other catalog translates
+var en = catalog{
+    "find.failed": {"Cannot find '%s': %s"},
+    "usage": {"Usage: %s"},
+    "game.first": {"Enter game mode first!"},
+    "muted.you": {"You are muted"},
+    "command.unknown": {"Unknown command: '%s'"},
+    "chat.first": {"Enter chat mode first!"},
+    "args.bad": {"Bad arguments for %s: %s. Usage: %s"},
+    "args.missing": {"%s is missing"},
+    "args.integer": {"%s should be an integer, not '%s'"},
+    "args.extra": {"too many arguments: %s"},
+    "only.master": {"Only master can run %s!"},
+    "only.countdown": {"Only master can launch countdown!"},
+    "only.reset": {"Only master can reset the game!"},
+    "only.game": {"Only master can switch to game mode!"},
+    "only.chat": {"Only master can switch to chat mode!"},
+    "only.judge": {"Only master can judge answers!"},
+    "only.exit": {"Only master can close the room!"},
+    "only.pause": {"Only master can pause the countdown!"},
+    "only.resume": {"Only master can resume the countdown!"},
+    "only.ban": {"Only master can ban players!"},
+    "only.unban": {"Only master can lift bans!"},
+    "only.bans": {"Only master can see the ban list!"},
+    "only.kick": {"Only master can kick players!"},
+    "only.mute": {"Only master can mute players!"},
+    "only.falsestart": {"Only master can change the false start rule!"},
+    "only.give": {"Only master can hand the game over!"},
+    "only.comaster": {"Only master can appoint co-masters!"},
+    "only.masters": {"Only master and co-masters can talk there!"},
+    "only.teamchat": {"Only master can turn team chat on and off!"},
+    "only.match": {"Only master can run matches!"},
+    "only.pack": {"Only master can load question packs!"},
+    "only.next": {"Only master can ask questions!"},
+    "only.reveal": {"Only master can reveal answers!"},
+    "only.tournament": {"Only master can run tournaments!"},
+    "only.undo": {"Only master can undo commands!"},
+    "master.new": {"%s is now the master of the game"},
+    "master.gone": {"%s is no longer the master of the game"},
+    "rename.done": {"%s is now known as %s"},
+    "rename.failed": {"Cannot rename to '%s': %s"},
+    "kick.you": {"You have been kicked"},
+    "kick.you.reason": {"You have been kicked: %s"},
+    "kick.done": {"%s has been kicked"},
+    "judge.nothing": {"Nothing to judge yet"},
+    "judge.right": {"%s is right! Score: %d"},
+    "judge.wrong": {"%s is wrong"},
+    "timer.seconds": {"===========%d second===========", "===========%d seconds==========="},
+    "timer.out": {"===========Time is Out==========="},
+    "timer.paused": {"===========Paused, %d second left===========", "===========Paused, %d seconds left==========="},
+    "timer.cancelled": {"===========Countdown cancelled==========="},
+    "timer.not_running": {"The countdown is not running"},
+    "timer.not_paused": {"The countdown is not paused"},
+    "reset.done": {"======Game reset======"},
+    "mode.game": {"===========Game Mode On==========="},
+    "mode.chat": {"===========Chat Mode On==========="},
+    "room.shutdown": {"Room %s will be closed!"},
+    "press.cannot": {"You can't press button now"},
+    "press.paused": {"The countdown is paused"},
+    "press.answer": {"%s, your answer?"},
+    "chat.cannot": {"You can't chat right now!"},
+    "join.done": {"'%s' has joined us!"},
+    "name.taken": {"the name is taken"},
+    "name.empty": {"the name is empty"},
+    "name.banned": {"the name is banned"},
+    "name.reserved": {"the name looks like a server one"},
+    "name.control": {"the name has control characters"},
+    "name.too_long": {"the name is longer than %d character", "the name is longer than %d characters"},
+    "err.room_closed": {"room has been shut down"},
+    "err.no_such_client": {"no such client"},
+    "err.has_master": {"the game has a master already"},
+    "err.no_master": {"the game has no master"},
+    "err.ambiguous_name": {"several players have that name, use #id from :who"},
+    "lang.current": {"Language: %s, available: %s"},
+    "lang.unknown": {"Usage: :lang <language>, one of: %s"},
+    "lang.set": {"Server messages are in %s now"},
+    "lang.en": {"English"},
+    "lang.ru": {"Russian"},
+    "ban.address.done": {"Banned address %s"},
+    "ban.self": {"You cannot ban yourself"},
+    "ban.not_banned": {"%s is not banned"},
+    "ban.lifted": {"Ban of %s is lifted"},
+    "ban.none": {"Nobody is banned"},
+    "reason.by": {"by %s"},
+    "ban.banned": {"banned"},
+    "ban.entry.address": {"address %s%s%s"},
+    "ban.entry.name": {"name %s%s%s"},
+    "ban.until": {" until %s"},
+    "ban.by": {" (by %s)"},
+    "ban.you": {"You are banned"},
+    "kick.self": {"You cannot kick yourself"},
+    "msg.self": {"Talking to yourself?"},
+    "channel.team": {"[%s -> team %s] %s"},
+    "channel.masters": {"[%s -> masters] %s"},
+    "team.none": {"You are not in a team, join one with :team <name>"},
+    "team.members": {"Team %s: %s"},
+    "team.not_in": {"You are not in a team"},
+    "team.left.you": {"You have left team %s"},
+    "team.left": {"%s has left team %s"},
+    "team.bad_name": {"Team names are printable and at most %d character long", "Team names are printable and at most %d characters long"},
+    "team.already": {"You are in team %s already"},
+    "team.joined": {"%s has joined team %s"},
+    "team.asked": {"You have asked team %s to let you in"},
+    "team.ask": {"%s asks to join team %s, let them in with :team accept #%d"},
+    "team.not_asked": {"%s has not asked to join team %s"},
+    "teamchat.off.you": {"Team chat is off"},
+    "teamchat.on.you": {"Team chat is on"},
+    "teamchat.on": {"===========Team chat is on==========="},
+    "teamchat.off": {"===========Team chat is off==========="},
+    "falsestart.rule": {"False start rule: %s"},
+    "falsestart.early": {"Too early, press again"},
+    "falsestart.done": {"%s has a false start!"},
+    "falsestart.loses": {"%s loses %s. Score: %d"},
+    "falsestart.lockout": {"lockout, a false start costs the right to answer the question"},
+    "falsestart.award": {"award, a false start gives the opponent the right to answer"},
+    "falsestart.penalty": {"penalty, a false start costs %s"},
+    "falsestart.grace": {"grace, presses before the start and within %dms after it are ignored"},
+    "points": {"%d point", "%d points"},
+    "who.online": {"Players online: %s"},
+    "flood.slow": {"Slow down!"},
+    "flood.too_long": {"Line too long, %d byte at most", "Line too long, %d bytes at most"},
+    "flood.flooding": {"flooding"},
+    "mute.done": {"%s is muted: %s"},
+    "mute.done.for": {"%s is muted for %d second: %s", "%s is muted for %d seconds: %s"},
+    "mute.lifted": {"%s can chat again"},
+    "mute.not_muted": {"%s is not muted"},
+    "history.nothing": {"Nothing has happened here yet"},
+    "history.earlier": {"Earlier in room %s"},
+    "history.away": {"While you were away"},
+    "history.last": {"Last %d message in room %s", "Last %d messages in room %s"},
+    "master.lost.wait": {"The master has lost connection and may come back, try again later"},
+    "master.has": {"The game has a master already"},
+    "master.already": {"You are the master already"},
+    "master.team": {"A team of the match cannot be the master"},
+    "master.given": {"%s has handed the game over to %s"},
+    "comaster.none": {"No co-masters"},
+    "comaster.list": {"Co-masters: %s"},
+    "comaster.not": {"%s is not a co-master"},
+    "comaster.gone": {"%s is no longer a co-master"},
+    "comaster.self": {"The master has all the rights already"},
+    "comaster.team": {"A team of the match cannot be a co-master"},
+    "comaster.new": {"%s is a co-master now: %s"},
+    "master.lost": {"The master has lost connection, waiting %d second for them to come back", "The master has lost connection, waiting %d seconds for them to come back"},
+    "master.failover": {"The master has not come back, %s takes over"},
+    "master.vacant": {"The master has not come back, anyone can take over with :master"},
+    "match.none": {"No match is being played"},
+    "match.status.of": {"Question %d of %d. %s"},
+    "match.status": {"Question %d. %s"},
+    "match.status.extra": {"Tie! Extra question %d. %s"},
+    "match.already": {"A match is being played already"},
+    "match.number": {"%s should be a number, not '%s'"},
+    "match.no_team": {"Cannot find team '%s': %s"},
+    "match.master": {"The master cannot play"},
+    "match.itself": {"A team cannot play against itself"},
+    "match.start": {"===========Match %s vs %s: %s==========="},
+    "match.goal.questions": {"%d question", "%d questions"},
+    "match.goal.target": {"first to %d point", "first to %d points"},
+    "match.goal.both": {"first to %d point, at most %s", "first to %d points, at most %s"},
+    "match.question": {"Question %d"},
+    "match.extra": {"Tie! Extra question %d"},
+    "match.question.of": {"Question %d of %d"},
+    "match.score": {"Score: %s %d - %d %s"},
+    "match.won": {"===========%s wins the match against %s, %d:%d==========="},
+    "match.stopped": {"===========Match stopped==========="},
+    "pack.failed": {"Cannot load pack: %s"},
+    "pack.loaded": {"Pack '%s' loaded: %d question", "Pack '%s' loaded: %d questions"},
+    "pack.first": {"Load a pack first!"},
+    "pack.over": {"No more questions in the pack"},
+    "question.text": {"Question %d: %s"},
+    "question.back": {"Back to question %d: %s"},
+    "bridge.on": {"Presses of this connection are dated by the bridge"},
+    "bridge.denied": {"Wrong bridge token"},
+    "question.none": {"No question has been asked yet"},
+    "question.answer": {"Answer: %s"},
+    "question.comment": {"Comment: %s"},
+    "room.already": {"You are in room %s already"},
+    "room.match": {"You cannot leave in the middle of a match"},
+    "room.name_taken": {"Someone in room %s goes by your name, rename first"},
+    "room.left": {"%s has left for room %s"},
+    "room.joined": {"'%s' has joined us in room %s!"},
+    "session.expired": {"Cannot resume the session, it has expired"},
+    "session.back": {"%s is back as %s"},
+    "tournament.on": {"The tournament is on, see :bracket"},
+    "tournament.no_teams": {"No teams registered yet"},
+    "tournament.teams": {"Teams registered: %s"},
+    "tournament.add_failed": {"Cannot add team %s: %s"},
+    "tournament.remove_failed": {"Cannot remove team %s: %s"},
+    "tournament.added": {"Team %s is registered for the tournament"},
+    "tournament.removed": {"Team %s is out of the tournament"},
+    "tournament.none": {"No tournament is being played"},
+    "tournament.stopped": {"===========The tournament is stopped==========="},
+    "tournament.format": {"Unknown format '%s', try one of: %v"},
+    "tournament.start_failed": {"Cannot start the tournament: %s"},
+    "tournament.started": {"===========The tournament (%s) has started: %s, %s==========="},
+    "tournament.teams.n": {"%d team", "%d teams"},
+    "tournament.matches.n": {"%d match", "%d matches"},
+    "tournament.scheduled": {"Match #%d %s vs %s is played in room %s"},
+    "tournament.champion": {"===========%s wins the tournament!==========="},
+    "tournament.err.started": {"the tournament has started already"},
+    "tournament.err.registered": {"it is registered already"},
+    "tournament.err.not_registered": {"it is not registered"},
+    "tournament.err.format": {"unknown tournament format"},
+    "tournament.err.too_few": {"at least two teams are needed"},
+    "tournament.err.duplicate": {"team names should be unique"},
+    "tournament.err.no_match": {"no such match"},
+    "tournament.err.not_playable": {"the match is over or its teams are not known yet"},
+    "tournament.match": {"#%d %s vs %s"},
+    "tournament.match.bye": {"#%d %s vs %s: %s goes through"},
+    "tournament.match.won": {"#%d %s vs %s: %s wins %d:%d"},
+    "tournament.match.room": {"#%d %s vs %s, playing in room %s"},
+    "tournament.bye": {"(bye)"},
+    "tournament.bracket": {"Tournament (%s), %s"},
+    "tournament.bracket.match": {"  %s"},
+    "tournament.round": {"Round %d"},
+    "tournament.round.winners": {"Winners round %d"},
+    "tournament.round.losers": {"Losers round %d"},
+    "tournament.grand_final": {"Grand final"},
+    "tournament.standings": {"Standings"},
+    "tournament.standing": {"  %d. %s: %d-%d, %s"},
+    "tournament.bracket.champion": {"Champion: %s"},
+    "help.commands": {"Commands: %s"},
+    "help.more": {"Type :help <command> for details"},
+    "help.unknown": {"No such command: '%s', see :help"},
+    "help.aliases": {"Also: %s"},
+    "help.role.master": {"Master only"},
+    "help.role.master.right": {"Master and co-masters with the %s right"},
+    "help.mode.game": {"In game mode only"},
+    "help.mode.chat": {"In chat mode only"},
+    "help.help": {"Lists the commands you can run or describes one"},
+    "help.rename": {"Changes your name"},
+    "help.who": {"Lists the players in the room"},
+    "help.lang": {"Shows or sets the language of server messages"},
+    "help.history": {"Shows the last messages of the room"},
+    "help.join": {"Moves you to another room, opening it if needed"},
+    "help.master": {"Takes the master's seat, hands it over or leaves it"},
+    "help.comaster": {"Lists, appoints or removes co-masters"},
+    "help.exit": {"Closes the room, everyone in it is disconnected"},
+    "help.game": {"Switches the room to game mode"},
+    "help.chat": {"Switches the room to chat mode, stopping a match"},
+    "help.reset": {"Lets everyone press the button again"},
+    "help.time": {"Starts the countdown"},
+    "help.pause": {"Puts the countdown on hold"},
+    "help.resume": {"Resumes the countdown"},
+    "help.accept": {"Accepts the answer, 1 point if not told otherwise"},
+    "help.reject": {"Rejects the answer"},
+    "help.falsestart": {"Shows or sets what a press before the countdown costs"},
+    "help.undo": {"Takes back the last game command"},
+    "help.redo": {"Redoes the command undone last"},
+    "help.pack": {"Loads a question pack"},
+    "help.next": {"Asks the next question of the pack"},
+    "help.reveal": {"Reveals the answer of the question to everyone"},
+    "help.match": {"Shows, starts or stops a match of two teams"},
+    "help.tournament": {"Registers teams, starts and stops a tournament"},
+    "help.bracket": {"Shows the tournament bracket"},
+    "help.mute": {"Keeps a player out of the chat, for good or for a while"},
+    "help.unmute": {"Lets a player chat again"},
+    "help.kick": {"Disconnects a player"},
+    "help.ban": {"Bans a player or an address, for good or for a while"},
+    "help.unban": {"Lifts a ban"},
+    "help.bans": {"Lists the bans"},
+    "help.msg": {"Sends a message to one player"},
+    "help.team": {"Shows your team, founds or asks to join one, lets a player in or leaves it"},
+    "help.t": {"Sends a message to your teammates"},
+    "help.masters": {"Sends a message to the master and co-masters"},
+    "help.teamchat": {"Shows, turns on or off team chat"},
+    "undo.nothing_redo": {"Nothing to redo"},
+    "undo.nothing": {"Nothing to undo"},
+    "undo.undone": {"%s has undone '%s'"},
+    "undo.redone": {"%s has redone '%s'"},
+    "undo.score": {"Score of %s: %d -> %d"},
+}
@@ -0,0 +1,142 @@
+package i18n
+
+
+import (
+    "fmt"
+    "sort"
+)
+
+// server messages in players' languages. A message is an id from the
+// catalogs below and fmt arguments; arguments that are messages themselves
+// are translated into the same language. Catalog entries have either one
+// form or plural forms picked by the first integer argument with the
+// language's plural rule. Missing translations fall back to English
+
+const Default = "en"
+
+type Message struct {
+    Id string
+    Args []interface{}
+}
+
+func M(id string, args ...interface{}) *Message {
+    return &Message{Id: id, Args: args}
+}
+
+// text that is the same in every language, e.g. what players have typed
+func Text(text string) *Message {
+    return &Message{Args: []interface{}{text}}
+}
+
+// forms of messages by id
+type catalog map[string][]string
+
+var catalogs = map[string]catalog{"en": en, "ru": ru}
+
+// index of the plural form for n
+var pluralRules = map[string]func(n int) int{
+    "en": func(n int) int {
+        if n == 1 {
+            return 0
+        }
+        return 1
+    },
+    "ru": func(n int) int {
+        if n < 0 {
+            n = -n
+        }
+        switch {
+        case n % 10 == 1 && n % 100 != 11:
+            return 0
+        case n % 10 >= 2 && n % 10 <= 4 && (n % 100 < 12 || n % 100 > 14):
+            return 1
+        }
+        return 2
+    },
+}
+
+func Supported(lang string) bool {
+    _, ok := catalogs[lang]
+    return ok
+}
+
+func Languages() []string {
+    var langs []string
+    for lang := range catalogs {
+        langs = append(langs, lang)
+    }
+    sort.Strings(langs)
+    return langs
+}
+
+// ids of the English catalog the language has no translation for
+func Missing(lang string) []string {
+    var ids []string
+    for id, forms := range en {
+        if translated, ok := catalogs[lang][id]; !ok || len(translated) == 1 && len(forms) > 1 {
+            ids = append(ids, id)
+        }
+    }
+    sort.Strings(ids)
+    return ids
+}
+
+// adds messages of the ones extending the server, e.g. help of a plugin's
+// command; to be called before the server starts
+func Add(lang string, id string, forms ...string) error {
+    if !Supported(lang) {
+        return fmt.Errorf("no such language '%s'", lang)
+    }
+    if len(forms) == 0 {
+        return fmt.Errorf("no text for %s", id)
+    }
+    catalogs[lang][id] = forms
+    return nil
+}
+
+func (m *Message) In(lang string) string {
+    args := make([]interface{}, len(m.Args))
+    for i, arg := range m.Args {
+        if msg, ok := arg.(*Message); ok {
+            args[i] = msg.In(lang)
+        } else {
+            args[i] = arg
+        }
+    }
+    if m.Id == "" {
+        return fmt.Sprint(args...)
+    }
+    forms, ok := catalogs[lang][m.Id]
+    if !ok {
+        lang = Default
+        if forms, ok = en[m.Id]; !ok {
+            return fmt.Sprintf("%s %v", m.Id, args)
+        }
+    }
+    return fmt.Sprintf(forms[pluralForm(lang, forms, args)], args...)
+}
+
+func pluralForm(lang string, forms []string, args []interface{}) int {
+    if len(forms) == 1 {
+        return 0
+    }
+    for _, arg := range args {
+        if n, ok := arg.(int); ok {
+            if i := pluralRules[lang](n); i < len(forms) {
+                return i
+            }
+            return len(forms) - 1
+        }
+    }
+    return len(forms) - 1
+}
+
+func (m *Message) String() string {
+    return m.In(Default)
+}
+
+// messages are errors as well, so that they can be translated once they
+// reach a player
+func (m *Message) Error() string {
+    return m.String()
+}
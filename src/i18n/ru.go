@@ -0,0 +1,274 @@
+package i18n
+
+
+// Russian messages, plural forms are one, few and many
+var ru = catalog{
+    "find.failed": {"Не удалось найти '%s': %s"},
+    "usage": {"Использование: %s"},
+    "game.first": {"Сначала включите режим игры!"},
+    "muted.you": {"Вам запрещено писать в чат"},
+    "command.unknown": {"Неизвестная команда: '%s'"},
+    "chat.first": {"Сначала включите режим чата!"},
+    "args.bad": {"Неверные аргументы %s: %s. Использование: %s"},
+    "args.missing": {"не хватает %s"},
+    "args.integer": {"%s должно быть целым числом, а не '%s'"},
+    "args.extra": {"лишние аргументы: %s"},
+    "only.master": {"Только ведущий может выполнять %s!"},
+    "only.countdown": {"Только ведущий может запустить отсчёт!"},
+    "only.reset": {"Только ведущий может сбросить игру!"},
+    "only.game": {"Только ведущий может включить режим игры!"},
+    "only.chat": {"Только ведущий может включить режим чата!"},
+    "only.judge": {"Только ведущий может оценивать ответы!"},
+    "only.exit": {"Только ведущий может закрыть комнату!"},
+    "only.pause": {"Только ведущий может приостановить отсчёт!"},
+    "only.resume": {"Только ведущий может продолжить отсчёт!"},
+    "only.ban": {"Только ведущий может банить игроков!"},
+    "only.unban": {"Только ведущий может снимать баны!"},
+    "only.bans": {"Только ведущий может смотреть список банов!"},
+    "only.kick": {"Только ведущий может выгонять игроков!"},
+    "only.mute": {"Только ведущий может запрещать игрокам писать в чат!"},
+    "only.falsestart": {"Только ведущий может менять правило фальстарта!"},
+    "only.give": {"Только ведущий может передать игру!"},
+    "only.comaster": {"Только ведущий может назначать соведущих!"},
+    "only.masters": {"Здесь могут писать только ведущий и соведущие!"},
+    "only.teamchat": {"Только ведущий может включать и выключать командный чат!"},
+    "only.match": {"Только ведущий может проводить матчи!"},
+    "only.pack": {"Только ведущий может загружать пакеты вопросов!"},
+    "only.next": {"Только ведущий может задавать вопросы!"},
+    "only.reveal": {"Только ведущий может открывать ответы!"},
+    "only.tournament": {"Только ведущий может проводить турниры!"},
+    "only.undo": {"Только ведущий может отменять команды!"},
+    "master.new": {"%s теперь ведущий игры"},
+    "master.gone": {"%s больше не ведущий игры"},
+    "rename.done": {"%s теперь известен как %s"},
+    "rename.failed": {"Не удалось сменить имя на '%s': %s"},
+    "kick.you": {"Вас выгнали"},
+    "kick.you.reason": {"Вас выгнали: %s"},
+    "kick.done": {"%s выгнан"},
+    "judge.nothing": {"Пока нечего оценивать"},
+    "judge.right": {"%s отвечает верно! Счёт: %d"},
+    "judge.wrong": {"%s отвечает неверно"},
+    "timer.seconds": {"===========%d секунда===========", "===========%d секунды===========", "===========%d секунд==========="},
+    "timer.out": {"===========Время вышло==========="},
+    "timer.paused": {"===========Пауза, осталась %d секунда===========", "===========Пауза, осталось %d секунды===========", "===========Пауза, осталось %d секунд==========="},
+    "timer.cancelled": {"===========Отсчёт отменён==========="},
+    "timer.not_running": {"Отсчёт не идёт"},
+    "timer.not_paused": {"Отсчёт не на паузе"},
+    "reset.done": {"======Игра сброшена======"},
+    "mode.game": {"===========Режим игры==========="},
+    "mode.chat": {"===========Режим чата==========="},
+    "room.shutdown": {"Комната %s будет закрыта!"},
+    "press.cannot": {"Сейчас нельзя нажимать кнопку"},
+    "press.paused": {"Отсчёт на паузе"},
+    "press.answer": {"%s, ваш ответ?"},
+    "chat.cannot": {"Сейчас нельзя писать в чат!"},
+    "join.done": {"'%s' присоединяется к нам!"},
+    "name.taken": {"имя занято"},
+    "name.empty": {"имя пустое"},
+    "name.banned": {"имя забанено"},
+    "name.reserved": {"имя похоже на служебное"},
+    "name.control": {"в имени есть управляющие символы"},
+    "name.too_long": {"имя длиннее %d символа", "имя длиннее %d символов", "имя длиннее %d символов"},
+    "err.room_closed": {"комната закрыта"},
+    "err.no_such_client": {"нет такого игрока"},
+    "err.has_master": {"у игры уже есть ведущий"},
+    "err.no_master": {"у игры нет ведущего"},
+    "err.ambiguous_name": {"это имя у нескольких игроков, используйте #id из :who"},
+    "lang.current": {"Язык: %s, доступны: %s"},
+    "lang.unknown": {"Использование: :lang <язык>, один из: %s"},
+    "lang.set": {"Теперь сообщения сервера на %s"},
+    "lang.en": {"английском"},
+    "lang.ru": {"русском"},
+    "ban.address.done": {"Адрес %s забанен"},
+    "ban.self": {"Нельзя забанить самого себя"},
+    "ban.not_banned": {"%s не забанен"},
+    "ban.lifted": {"Бан %s снят"},
+    "ban.none": {"Никто не забанен"},
+    "reason.by": {"от %s"},
+    "ban.banned": {"бан"},
+    "ban.entry.address": {"адрес %s%s%s"},
+    "ban.entry.name": {"имя %s%s%s"},
+    "ban.until": {" до %s"},
+    "ban.by": {" (от %s)"},
+    "ban.you": {"Вы забанены"},
+    "kick.self": {"Нельзя выгнать самого себя"},
+    "msg.self": {"Разговариваете сами с собой?"},
+    "channel.team": {"[%s -> команда %s] %s"},
+    "channel.masters": {"[%s -> ведущие] %s"},
+    "team.none": {"Вы не в команде, вступите в неё командой :team <название>"},
+    "team.members": {"Команда %s: %s"},
+    "team.not_in": {"Вы не в команде"},
+    "team.left.you": {"Вы вышли из команды %s"},
+    "team.left": {"%s выходит из команды %s"},
+    "team.bad_name": {"Название команды должно быть печатным и не длиннее %d символа", "Название команды должно быть печатным и не длиннее %d символов", "Название команды должно быть печатным и не длиннее %d символов"},
+    "team.already": {"Вы уже в команде %s"},
+    "team.joined": {"%s вступает в команду %s"},
+    "team.asked": {"Вы попросились в команду %s"},
+    "team.ask": {"%s просится в команду %s, примите командой :team accept #%d"},
+    "team.not_asked": {"%s не просится в команду %s"},
+    "teamchat.off.you": {"Командный чат выключен"},
+    "teamchat.on.you": {"Командный чат включён"},
+    "teamchat.on": {"===========Командный чат включён==========="},
+    "teamchat.off": {"===========Командный чат выключен==========="},
+    "falsestart.rule": {"Правило фальстарта: %s"},
+    "falsestart.early": {"Слишком рано, нажмите ещё раз"},
+    "falsestart.done": {"%s делает фальстарт!"},
+    "falsestart.loses": {"%s теряет %s. Счёт: %d"},
+    "falsestart.lockout": {"блокировка, фальстарт лишает права отвечать на вопрос"},
+    "falsestart.award": {"передача, после фальстарта отвечает соперник"},
+    "falsestart.penalty": {"штраф, фальстарт стоит %s"},
+    "falsestart.grace": {"допуск, нажатия до старта и в течение %d мс после него не считаются"},
+    "points": {"%d очко", "%d очка", "%d очков"},
+    "who.online": {"Игроки в сети: %s"},
+    "flood.slow": {"Не так быстро!"},
+    "flood.too_long": {"Слишком длинная строка, не больше %d байта", "Слишком длинная строка, не больше %d байт", "Слишком длинная строка, не больше %d байт"},
+    "flood.flooding": {"флуд"},
+    "mute.done": {"%s не может писать в чат: %s"},
+    "mute.done.for": {"%s не может писать в чат %d секунду: %s", "%s не может писать в чат %d секунды: %s", "%s не может писать в чат %d секунд: %s"},
+    "mute.lifted": {"%s снова может писать в чат"},
+    "mute.not_muted": {"%s и так может писать в чат"},
+    "history.nothing": {"Здесь пока ничего не происходило"},
+    "history.earlier": {"Ранее в комнате %s"},
+    "history.away": {"Пока вас не было"},
+    "history.last": {"Последнее %d сообщение в комнате %s", "Последние %d сообщения в комнате %s", "Последние %d сообщений в комнате %s"},
+    "master.lost.wait": {"Ведущий потерял связь и может вернуться, попробуйте позже"},
+    "master.has": {"У игры уже есть ведущий"},
+    "master.already": {"Вы уже ведущий"},
+    "master.team": {"Команда, играющая матч, не может быть ведущим"},
+    "master.given": {"%s передаёт игру %s"},
+    "comaster.none": {"Соведущих нет"},
+    "comaster.list": {"Соведущие: %s"},
+    "comaster.not": {"%s не соведущий"},
+    "comaster.gone": {"%s больше не соведущий"},
+    "comaster.self": {"У ведущего и так есть все права"},
+    "comaster.team": {"Команда, играющая матч, не может быть соведущим"},
+    "comaster.new": {"%s теперь соведущий: %s"},
+    "master.lost": {"Ведущий потерял связь, ждём его возвращения %d секунду", "Ведущий потерял связь, ждём его возвращения %d секунды", "Ведущий потерял связь, ждём его возвращения %d секунд"},
+    "master.failover": {"Ведущий не вернулся, игру ведёт %s"},
+    "master.vacant": {"Ведущий не вернулся, любой может стать ведущим командой :master"},
+    "match.none": {"Матч не идёт"},
+    "match.status.of": {"Вопрос %d из %d. %s"},
+    "match.status": {"Вопрос %d. %s"},
+    "match.status.extra": {"Ничья! Дополнительный вопрос %d. %s"},
+    "match.already": {"Матч уже идёт"},
+    "match.number": {"%s должно быть числом, а не '%s'"},
+    "match.no_team": {"Не удалось найти команду '%s': %s"},
+    "match.master": {"Ведущий не может играть"},
+    "match.itself": {"Команда не может играть сама с собой"},
+    "match.start": {"===========Матч %s против %s: %s==========="},
+    "match.goal.questions": {"%d вопрос", "%d вопроса", "%d вопросов"},
+    "match.goal.target": {"до %d очка", "до %d очков", "до %d очков"},
+    "match.goal.both": {"до %d очка, не больше %s", "до %d очков, не больше %s", "до %d очков, не больше %s"},
+    "match.question": {"Вопрос %d"},
+    "match.extra": {"Ничья! Дополнительный вопрос %d"},
+    "match.question.of": {"Вопрос %d из %d"},
+    "match.score": {"Счёт: %s %d - %d %s"},
+    "match.won": {"===========%s выигрывает матч у %s, %d:%d==========="},
+    "match.stopped": {"===========Матч остановлен==========="},
+    "pack.failed": {"Не удалось загрузить пакет: %s"},
+    "pack.loaded": {"Пакет '%s' загружен: %d вопрос", "Пакет '%s' загружен: %d вопроса", "Пакет '%s' загружен: %d вопросов"},
+    "pack.first": {"Сначала загрузите пакет!"},
+    "pack.over": {"В пакете больше нет вопросов"},
+    "question.text": {"Вопрос %d: %s"},
+    "question.back": {"Возвращаемся к вопросу %d: %s"},
+    "bridge.on": {"Нажатия этого подключения датирует мост"},
+    "bridge.denied": {"Неверный токен моста"},
+    "question.none": {"Вопрос ещё не задан"},
+    "question.answer": {"Ответ: %s"},
+    "question.comment": {"Комментарий: %s"},
+    "room.already": {"Вы уже в комнате %s"},
+    "room.match": {"Нельзя уйти посреди матча"},
+    "room.name_taken": {"В комнате %s уже есть игрок с вашим именем, смените имя"},
+    "room.left": {"%s уходит в комнату %s"},
+    "room.joined": {"'%s' присоединяется к нам в комнате %s!"},
+    "session.expired": {"Не удалось восстановить сессию, она истекла"},
+    "session.back": {"%s возвращается как %s"},
+    "tournament.on": {"Турнир идёт, см. :bracket"},
+    "tournament.no_teams": {"Пока не зарегистрировано ни одной команды"},
+    "tournament.teams": {"Зарегистрированы команды: %s"},
+    "tournament.add_failed": {"Не удалось добавить команду %s: %s"},
+    "tournament.remove_failed": {"Не удалось убрать команду %s: %s"},
+    "tournament.added": {"Команда %s зарегистрирована на турнир"},
+    "tournament.removed": {"Команда %s выбывает из турнира"},
+    "tournament.none": {"Турнир не идёт"},
+    "tournament.stopped": {"===========Турнир остановлен==========="},
+    "tournament.format": {"Неизвестный формат '%s', попробуйте один из: %v"},
+    "tournament.start_failed": {"Не удалось начать турнир: %s"},
+    "tournament.started": {"===========Турнир (%s) начался: %s, %s==========="},
+    "tournament.teams.n": {"%d команда", "%d команды", "%d команд"},
+    "tournament.matches.n": {"%d матч", "%d матча", "%d матчей"},
+    "tournament.scheduled": {"Матч №%d %s против %s играется в комнате %s"},
+    "tournament.champion": {"===========%s выигрывает турнир!==========="},
+    "tournament.err.started": {"турнир уже начался"},
+    "tournament.err.registered": {"она уже зарегистрирована"},
+    "tournament.err.not_registered": {"она не зарегистрирована"},
+    "tournament.err.format": {"неизвестный формат турнира"},
+    "tournament.err.too_few": {"нужны хотя бы две команды"},
+    "tournament.err.duplicate": {"названия команд не должны повторяться"},
+    "tournament.err.no_match": {"нет такого матча"},
+    "tournament.err.not_playable": {"матч уже сыгран или его команды ещё не известны"},
+    "tournament.match": {"№%d %s против %s"},
+    "tournament.match.bye": {"№%d %s против %s: %s проходит дальше"},
+    "tournament.match.won": {"№%d %s против %s: %s побеждает %d:%d"},
+    "tournament.match.room": {"№%d %s против %s, играется в комнате %s"},
+    "tournament.bye": {"(без соперника)"},
+    "tournament.bracket": {"Турнир (%s), %s"},
+    "tournament.bracket.match": {"  %s"},
+    "tournament.round": {"Тур %d"},
+    "tournament.round.winners": {"Верхняя сетка, тур %d"},
+    "tournament.round.losers": {"Нижняя сетка, тур %d"},
+    "tournament.grand_final": {"Суперфинал"},
+    "tournament.standings": {"Турнирная таблица"},
+    "tournament.standing": {"  %d. %s: %d-%d, %s"},
+    "tournament.bracket.champion": {"Чемпион: %s"},
+    "help.commands": {"Команды: %s"},
+    "help.more": {"Подробнее: :help <команда>"},
+    "help.unknown": {"Нет такой команды: '%s', см. :help"},
+    "help.aliases": {"Также: %s"},
+    "help.role.master": {"Только для ведущего"},
+    "help.role.master.right": {"Для ведущего и соведущих с правом %s"},
+    "help.mode.game": {"Только в режиме игры"},
+    "help.mode.chat": {"Только в режиме чата"},
+    "help.help": {"Показывает доступные команды или описание одной из них"},
+    "help.rename": {"Меняет ваше имя"},
+    "help.who": {"Показывает игроков в комнате"},
+    "help.lang": {"Показывает или меняет язык сообщений сервера"},
+    "help.history": {"Показывает последние сообщения комнаты"},
+    "help.join": {"Переводит вас в другую комнату, открывая её при необходимости"},
+    "help.master": {"Делает вас ведущим, передаёт игру или снимает с вас роль ведущего"},
+    "help.comaster": {"Показывает, назначает или снимает соведущих"},
+    "help.exit": {"Закрывает комнату, все в ней отключаются"},
+    "help.game": {"Включает режим игры"},
+    "help.chat": {"Включает режим чата, останавливая матч"},
+    "help.reset": {"Снова разрешает всем нажимать кнопку"},
+    "help.time": {"Запускает отсчёт"},
+    "help.pause": {"Приостанавливает отсчёт"},
+    "help.resume": {"Продолжает отсчёт"},
+    "help.accept": {"Засчитывает ответ, 1 очко, если не указано иное"},
+    "help.reject": {"Не засчитывает ответ"},
+    "help.falsestart": {"Показывает или меняет правило фальстарта"},
+    "help.undo": {"Отменяет последнюю игровую команду"},
+    "help.redo": {"Повторяет последнюю отменённую команду"},
+    "help.pack": {"Загружает пакет вопросов"},
+    "help.next": {"Задаёт следующий вопрос пакета"},
+    "help.reveal": {"Открывает всем ответ на вопрос"},
+    "help.match": {"Показывает, начинает или останавливает матч двух команд"},
+    "help.tournament": {"Регистрирует команды, начинает и останавливает турнир"},
+    "help.bracket": {"Показывает сетку турнира"},
+    "help.mute": {"Запрещает игроку писать в чат, навсегда или на время"},
+    "help.unmute": {"Снова разрешает игроку писать в чат"},
+    "help.kick": {"Отключает игрока"},
+    "help.ban": {"Банит игрока или адрес, навсегда или на время"},
+    "help.unban": {"Снимает бан"},
+    "help.bans": {"Показывает список банов"},
+    "help.msg": {"Отправляет сообщение одному игроку"},
+    "help.team": {"Показывает вашу команду, создаёт команду или просится в неё, принимает игрока или выходит из команды"},
+    "help.t": {"Отправляет сообщение вашей команде"},
+    "help.masters": {"Отправляет сообщение ведущему и соведущим"},
+    "help.teamchat": {"Показывает состояние командного чата, включает или выключает его"},
+    "undo.nothing_redo": {"Нечего повторять"},
+    "undo.nothing": {"Нечего отменять"},
+    "undo.undone": {"%s отменяет '%s'"},
+    "undo.redone": {"%s повторяет '%s'"},
+    "undo.score": {"Счёт %s: %d -> %d"},
+}
@@ -0,0 +1,166 @@
+package logger
+
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+type Level int
+
+const (
+    Debug Level = iota
+    Info
+    Warn
+    Error
+)
+
+var levelNames = []string{"debug", "info", "warn", "error"}
+
+func (level Level) String() string {
+    if level < Debug || level > Error {
+        return fmt.Sprintf("level(%d)", int(level))
+    }
+    return levelNames[level]
+}
+
+func ParseLevel(name string) (Level, error) {
+    for i, levelName := range levelNames {
+        if strings.EqualFold(name, levelName) {
+            return Level(i), nil
+        }
+    }
+    return Info, fmt.Errorf("unknown log level '%s'", name)
+}
+
+// shared by a logger and all the loggers derived from it with With
+type output struct {
+    mu sync.Mutex
+    w io.Writer
+    level Level
+    json bool
+}
+
+// Logger writes leveled records with key=value context fields,
+// either as text lines or as JSON objects
+type Logger struct {
+    out *output
+    // alternating keys and values
+    fields []interface{}
+}
+
+func New(w io.Writer, level Level, asJSON bool) *Logger {
+    return &Logger{out: &output{w: w, level: level, json: asJSON}}
+}
+
+// a logger that throws everything away, handy for tests
+func Quiet() *Logger {
+    return New(ioutil.Discard, Error + 1, false)
+}
+
+var defaultMu sync.Mutex
+var defaultLogger = New(os.Stdout, Info, false)
+
+func Default() *Logger {
+    defaultMu.Lock()
+    defer defaultMu.Unlock()
+    return defaultLogger
+}
+
+func SetDefault(log *Logger) {
+    defaultMu.Lock()
+    defer defaultMu.Unlock()
+    defaultLogger = log
+}
+
+// returns a logger adding the given key/value pairs to every record
+func (log *Logger) With(keyValues ...interface{}) *Logger {
+    fields := make([]interface{}, 0, len(log.fields) + len(keyValues))
+    fields = append(fields, log.fields...)
+    fields = append(fields, keyValues...)
+    return &Logger{out: log.out, fields: fields}
+}
+
+func (log *Logger) Enabled(level Level) bool {
+    return level >= log.out.level
+}
+
+func (log *Logger) Debug(msg string, keyValues ...interface{}) {
+    log.write(Debug, msg, keyValues)
+}
+
+func (log *Logger) Info(msg string, keyValues ...interface{}) {
+    log.write(Info, msg, keyValues)
+}
+
+func (log *Logger) Warn(msg string, keyValues ...interface{}) {
+    log.write(Warn, msg, keyValues)
+}
+
+func (log *Logger) Error(msg string, keyValues ...interface{}) {
+    log.write(Error, msg, keyValues)
+}
+
+func (log *Logger) write(level Level, msg string, keyValues []interface{}) {
+    if !log.Enabled(level) {
+        return
+    }
+    fields := append(append([]interface{}(nil), log.fields...), keyValues...)
+    now := time.Now()
+    var line string
+    if log.out.json {
+        line = formatJSON(now, level, msg, fields)
+    } else {
+        line = formatText(now, level, msg, fields)
+    }
+    log.out.mu.Lock()
+    defer log.out.mu.Unlock()
+    io.WriteString(log.out.w, line)
+}
+
+func fieldValue(value interface{}) interface{} {
+    switch v := value.(type) {
+    case error:
+        return v.Error()
+    case fmt.Stringer:
+        return v.String()
+    }
+    return value
+}
+
+func formatJSON(now time.Time, level Level, msg string, fields []interface{}) string {
+    record := map[string]interface{}{
+        "time": now.Format(time.RFC3339Nano),
+        "level": level.String(),
+        "msg": msg,
+    }
+    for i := 0; i+1 < len(fields); i += 2 {
+        record[fmt.Sprint(fields[i])] = fieldValue(fields[i+1])
+    }
+    data, err := json.Marshal(record)
+    if err != nil {
+        return fmt.Sprintf("{\"level\":\"error\",\"msg\":\"cannot encode log record: %s\"}\n", err)
+    }
+    return string(data) + "\n"
+}
+
+func formatText(now time.Time, level Level, msg string, fields []interface{}) string {
+    var b strings.Builder
+    fmt.Fprintf(&b, "%s %-5s %s", now.Format("2006-01-02 15:04:05.000"),
+                strings.ToUpper(level.String()), msg)
+    for i := 0; i+1 < len(fields); i += 2 {
+        value := fmt.Sprint(fieldValue(fields[i+1]))
+        if value == "" || strings.ContainsAny(value, " \t\"=") {
+            value = fmt.Sprintf("%q", value)
+        }
+        fmt.Fprintf(&b, " %v=%s", fields[i], value)
+    }
+    b.WriteByte('\n')
+    return b.String()
+}
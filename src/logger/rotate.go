@@ -0,0 +1,74 @@
+package logger
+
+
+import (
+    "fmt"
+    "os"
+    "sync"
+)
+
+// RotatingFile is a log file renamed to file.1, file.2, ... once it
+// grows over maxSize bytes, keeping at most maxBackups old files
+type RotatingFile struct {
+    mu sync.Mutex
+    path string
+    maxSize int64
+    maxBackups int
+    file *os.File
+    size int64
+}
+
+func OpenRotating(path string, maxSize int64, maxBackups int) (*RotatingFile, error) {
+    rf := &RotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+    if err := rf.open(); err != nil {
+        return nil, err
+    }
+    return rf, nil
+}
+
+func (rf *RotatingFile) open() error {
+    file, err := os.OpenFile(rf.path, os.O_CREATE | os.O_WRONLY | os.O_APPEND, 0644)
+    if err != nil {
+        return err
+    }
+    info, err := file.Stat()
+    if err != nil {
+        file.Close()
+        return err
+    }
+    rf.file = file
+    rf.size = info.Size()
+    return nil
+}
+
+func (rf *RotatingFile) rotate() error {
+    rf.file.Close()
+    if rf.maxBackups > 0 {
+        for i := rf.maxBackups - 1; i > 0; i-- {
+            os.Rename(fmt.Sprintf("%s.%d", rf.path, i), fmt.Sprintf("%s.%d", rf.path, i+1))
+        }
+        os.Rename(rf.path, rf.path + ".1")
+    } else {
+        os.Remove(rf.path)
+    }
+    return rf.open()
+}
+
+func (rf *RotatingFile) Write(data []byte) (int, error) {
+    rf.mu.Lock()
+    defer rf.mu.Unlock()
+    if rf.maxSize > 0 && rf.size > 0 && rf.size + int64(len(data)) > rf.maxSize {
+        if err := rf.rotate(); err != nil {
+            return 0, err
+        }
+    }
+    n, err := rf.file.Write(data)
+    rf.size += int64(n)
+    return n, err
+}
+
+func (rf *RotatingFile) Close() error {
+    rf.mu.Lock()
+    defer rf.mu.Unlock()
+    return rf.file.Close()
+}
@@ -0,0 +1,253 @@
+package metrics
+
+
+import (
+    "bytes"
+    "fmt"
+    "math"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+)
+
+// a tiny subset of Prometheus client: counters, gauges and histograms
+// with labels, exposed in the text format
+
+// latency buckets in seconds
+var DefBuckets = []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5}
+
+type Registry struct {
+    mu sync.Mutex
+    families []*family
+}
+
+func NewRegistry() *Registry {
+    return &Registry{}
+}
+
+type family struct {
+    name string
+    help string
+    kind string
+    labels []string
+    buckets []float64
+    mu sync.Mutex
+    // keyed by label values joined with \xff
+    samples map[string]*sample
+    // for values computed at scrape time
+    collect func(emit func(value float64, labelValues ...string))
+}
+
+type sample struct {
+    labelValues []string
+    value float64
+    // histogram only
+    counts []uint64
+    count uint64
+}
+
+func (registry *Registry) register(f *family) *family {
+    f.samples = make(map[string]*sample)
+    registry.mu.Lock()
+    registry.families = append(registry.families, f)
+    registry.mu.Unlock()
+    return f
+}
+
+func (f *family) with(labelValues []string) *sample {
+    if len(labelValues) != len(f.labels) {
+        panic(fmt.Sprintf("metric %s expects %d labels, got %d",
+                          f.name, len(f.labels), len(labelValues)))
+    }
+    key := strings.Join(labelValues, "\xff")
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    s, ok := f.samples[key]
+    if !ok {
+        s = &sample{labelValues: append([]string(nil), labelValues...)}
+        if f.buckets != nil {
+            s.counts = make([]uint64, len(f.buckets))
+        }
+        f.samples[key] = s
+    }
+    return s
+}
+
+type Counter struct {
+    f *family
+    s *sample
+}
+
+func (c Counter) Inc() {
+    c.Add(1)
+}
+
+func (c Counter) Add(v float64) {
+    c.f.mu.Lock()
+    c.s.value += v
+    c.f.mu.Unlock()
+}
+
+type CounterVec struct {
+    f *family
+}
+
+func (registry *Registry) NewCounter(name string, help string, labels ...string) *CounterVec {
+    return &CounterVec{registry.register(
+        &family{name: name, help: help, kind: "counter", labels: labels})}
+}
+
+func (vec *CounterVec) With(labelValues ...string) Counter {
+    return Counter{vec.f, vec.f.with(labelValues)}
+}
+
+type Gauge struct {
+    f *family
+    s *sample
+}
+
+func (g Gauge) Set(v float64) {
+    g.f.mu.Lock()
+    g.s.value = v
+    g.f.mu.Unlock()
+}
+
+func (g Gauge) Add(v float64) {
+    g.f.mu.Lock()
+    g.s.value += v
+    g.f.mu.Unlock()
+}
+
+type GaugeVec struct {
+    f *family
+}
+
+func (registry *Registry) NewGauge(name string, help string, labels ...string) *GaugeVec {
+    return &GaugeVec{registry.register(
+        &family{name: name, help: help, kind: "gauge", labels: labels})}
+}
+
+func (vec *GaugeVec) With(labelValues ...string) Gauge {
+    return Gauge{vec.f, vec.f.with(labelValues)}
+}
+
+// gauge whose values are computed by collect on every scrape
+func (registry *Registry) NewGaugeFunc(name string, help string, labels []string,
+                                       collect func(emit func(value float64, labelValues ...string))) {
+    registry.register(&family{name: name, help: help, kind: "gauge",
+                              labels: labels, collect: collect})
+}
+
+type Histogram struct {
+    f *family
+    s *sample
+}
+
+func (h Histogram) Observe(v float64) {
+    h.f.mu.Lock()
+    defer h.f.mu.Unlock()
+    for i, bound := range h.f.buckets {
+        if v <= bound {
+            h.s.counts[i]++
+        }
+    }
+    h.s.count++
+    h.s.value += v
+}
+
+type HistogramVec struct {
+    f *family
+}
+
+func (registry *Registry) NewHistogram(name string, help string, buckets []float64,
+                                       labels ...string) *HistogramVec {
+    return &HistogramVec{registry.register(
+        &family{name: name, help: help, kind: "histogram", labels: labels, buckets: buckets})}
+}
+
+func (vec *HistogramVec) With(labelValues ...string) Histogram {
+    return Histogram{vec.f, vec.f.with(labelValues)}
+}
+
+func formatValue(v float64) string {
+    if math.IsInf(v, 1) {
+        return "+Inf"
+    }
+    return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeLabel(v string) string {
+    v = strings.Replace(v, `\`, `\\`, -1)
+    v = strings.Replace(v, "\n", `\n`, -1)
+    return strings.Replace(v, `"`, `\"`, -1)
+}
+
+func formatLabels(names []string, values []string, extra ...string) string {
+    var pairs []string
+    for i, name := range names {
+        pairs = append(pairs, fmt.Sprintf(`%s="%s"`, name, escapeLabel(values[i])))
+    }
+    for i := 0; i+1 < len(extra); i += 2 {
+        pairs = append(pairs, fmt.Sprintf(`%s="%s"`, extra[i], escapeLabel(extra[i+1])))
+    }
+    if len(pairs) == 0 {
+        return ""
+    }
+    return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func (f *family) write(out *bytes.Buffer) {
+    fmt.Fprintf(out, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+    if f.collect != nil {
+        f.collect(func(value float64, labelValues ...string) {
+            fmt.Fprintf(out, "%s%s %s\n", f.name,
+                        formatLabels(f.labels, labelValues), formatValue(value))
+        })
+        return
+    }
+    f.mu.Lock()
+    defer f.mu.Unlock()
+    keys := make([]string, 0, len(f.samples))
+    for key := range f.samples {
+        keys = append(keys, key)
+    }
+    sort.Strings(keys)
+    for _, key := range keys {
+        s := f.samples[key]
+        if f.kind != "histogram" {
+            fmt.Fprintf(out, "%s%s %s\n", f.name,
+                        formatLabels(f.labels, s.labelValues), formatValue(s.value))
+            continue
+        }
+        for i, bound := range f.buckets {
+            fmt.Fprintf(out, "%s_bucket%s %d\n", f.name,
+                        formatLabels(f.labels, s.labelValues, "le", formatValue(bound)),
+                        s.counts[i])
+        }
+        fmt.Fprintf(out, "%s_bucket%s %d\n", f.name,
+                    formatLabels(f.labels, s.labelValues, "le", "+Inf"), s.count)
+        fmt.Fprintf(out, "%s_sum%s %s\n", f.name,
+                    formatLabels(f.labels, s.labelValues), formatValue(s.value))
+        fmt.Fprintf(out, "%s_count%s %d\n", f.name,
+                    formatLabels(f.labels, s.labelValues), s.count)
+    }
+}
+
+// writes every metric in Prometheus text format
+func (registry *Registry) WriteText(out *bytes.Buffer) {
+    registry.mu.Lock()
+    families := append([]*family(nil), registry.families...)
+    registry.mu.Unlock()
+    for _, f := range families {
+        f.write(out)
+    }
+}
+
+func (registry *Registry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    var out bytes.Buffer
+    registry.WriteText(&out)
+    w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+    w.Write(out.Bytes())
+}
@@ -0,0 +1,170 @@
+package pack
+
+
+import (
+    "os"
+    "regexp"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+)
+
+// the text format of the Russian quiz database, db.chgk.info: a field
+// starts with its name and a colon at the beginning of a line, the value
+// is on the same line or the ones after it, up to the next field
+//
+//   Чемпионат:
+//   Кубок Москвы
+//
+//   Вопрос 1:
+//   ...
+//
+//   Ответ:
+//   ...
+//
+// Зачёт, Комментарий, Источник and Автор may follow the answer, the fields
+// of the tournament other than its name are skipped. Old files are in
+// windows-1251, they are recoded
+
+var dbField = regexp.MustCompile(`^(Вопрос(?:\s+\d+)?|Ответ|Зач[её]т|Незач[её]т|Комментари[йи]|` +
+                                 `Источники?|Авторы?|Чемпионат|Турнир|Дата|Редакторы?|Инфо|Тур|` +
+                                 `Вид|Тип|Копирайт|Ссылка|URL)\s*:\s*(.*)$`)
+
+// the fields of a question after its text
+var dbQuestionFields = map[string]func(q *Question) *string{
+    "Ответ": func(q *Question) *string { return &q.Answer },
+    "Зачет": func(q *Question) *string { return &q.Accept },
+    "Зачёт": func(q *Question) *string { return &q.Accept },
+    "Комментарий": func(q *Question) *string { return &q.Comment },
+    "Комментарии": func(q *Question) *string { return &q.Comment },
+    "Источник": func(q *Question) *string { return &q.Source },
+    "Источники": func(q *Question) *string { return &q.Source },
+    "Автор": func(q *Question) *string { return &q.Author },
+    "Авторы": func(q *Question) *string { return &q.Author },
+}
+
+type dbEntry struct {
+    name string
+    line int
+    lines []string
+}
+
+// the value as a single line, the lines of the file are just wrapped
+func (e *dbEntry) value() string {
+    var words []string
+    for _, line := range e.lines {
+        if line = strings.TrimSpace(line); line != "" {
+            words = append(words, line)
+        }
+    }
+    return strings.Join(words, " ")
+}
+
+func readDBEntries(text string) []*dbEntry {
+    var entries []*dbEntry
+    for i, line := range strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n") {
+        if m := dbField.FindStringSubmatch(line); m != nil {
+            entries = append(entries, &dbEntry{name: m[1], line: i + 1, lines: []string{m[2]}})
+        } else if len(entries) > 0 {
+            last := entries[len(entries) - 1]
+            last.lines = append(last.lines, line)
+        }
+        // whatever comes before the first field is a preamble
+    }
+    return entries
+}
+
+func LoadDB(path string) (*Pack, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    text := string(data)
+    if !utf8.Valid(data) {
+        text = fromWindows1251(data)
+    }
+    p := &Pack{}
+    var q *Question
+    // where the question being read starts
+    qLine := 0
+    done := func() error {
+        if q == nil {
+            return nil
+        }
+        if q.Text == "" {
+            return errorAt(path, qLine, "question %d has no text", q.Number)
+        }
+        if q.Answer == "" {
+            return errorAt(path, qLine, "question %d has no answer", q.Number)
+        }
+        p.Questions = append(p.Questions, *q)
+        return nil
+    }
+    for _, e := range readDBEntries(strings.TrimPrefix(text, "\ufeff")) {
+        if strings.HasPrefix(e.name, "Вопрос") {
+            if err := done(); err != nil {
+                return nil, err
+            }
+            q = &Question{Number: len(p.Questions) + 1, Text: e.value()}
+            qLine = e.line
+            // tours may number their questions anew, the file's numbers are kept
+            if words := strings.Fields(e.name); len(words) == 2 {
+                q.Number, _ = strconv.Atoi(words[1])
+            }
+            continue
+        }
+        if (e.name == "Чемпионат" || e.name == "Турнир") && p.Title == "" {
+            p.Title = e.value()
+        }
+        field, ok := dbQuestionFields[e.name]
+        if !ok {
+            continue
+        }
+        if q == nil {
+            return nil, errorAt(path, e.line, "%s before any question", e.name)
+        }
+        if *field(q) != "" {
+            return nil, errorAt(path, e.line, "question %d has %s twice", q.Number, e.name)
+        }
+        *field(q) = e.value()
+    }
+    if err := done(); err != nil {
+        return nil, err
+    }
+    if len(p.Questions) == 0 {
+        return nil, errorAt(path, 0, "no questions found")
+    }
+    return p, nil
+}
+
+// the upper half of windows-1251, the lower one is ascii
+var windows1251 = [128]rune{
+    'Ђ', 'Ѓ', '‚', 'ѓ', '„', '…', '†', '‡',
+    '€', '‰', 'Љ', '‹', 'Њ', 'Ќ', 'Ћ', 'Џ',
+    'ђ', '‘', '’', '“', '”', '•', '–', '—',
+    '\ufffd', '™', 'љ', '›', 'њ', 'ќ', 'ћ', 'џ',
+    '\u00a0', 'Ў', 'ў', 'Ј', '¤', 'Ґ', '¦', '§',
+    'Ё', '©', 'Є', '«', '¬', '\u00ad', '®', 'Ї',
+    '°', '±', 'І', 'і', 'ґ', 'µ', '¶', '·',
+    'ё', '№', 'є', '»', 'ј', 'Ѕ', 'ѕ', 'ї',
+    'А', 'Б', 'В', 'Г', 'Д', 'Е', 'Ж', 'З',
+    'И', 'Й', 'К', 'Л', 'М', 'Н', 'О', 'П',
+    'Р', 'С', 'Т', 'У', 'Ф', 'Х', 'Ц', 'Ч',
+    'Ш', 'Щ', 'Ъ', 'Ы', 'Ь', 'Э', 'Ю', 'Я',
+    'а', 'б', 'в', 'г', 'д', 'е', 'ж', 'з',
+    'и', 'й', 'к', 'л', 'м', 'н', 'о', 'п',
+    'р', 'с', 'т', 'у', 'ф', 'х', 'ц', 'ч',
+    'ш', 'щ', 'ъ', 'ы', 'ь', 'э', 'ю', 'я',
+}
+
+func fromWindows1251(data []byte) string {
+    var b strings.Builder
+    for _, c := range data {
+        if c < 0x80 {
+            b.WriteByte(c)
+        } else {
+            b.WriteRune(windows1251[c - 0x80])
+        }
+    }
+    return b.String()
+}
@@ -0,0 +1,169 @@
+package pack
+
+
+import (
+    "bytes"
+    "encoding/json"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// a question pack: what the master reads out and what counts as an answer
+
+type Question struct {
+    Number int `json:"number"`
+    Text string `json:"text"`
+    Answer string `json:"answer"`
+    // other answers to be accepted
+    Accept string `json:"accept,omitempty"`
+    Comment string `json:"comment,omitempty"`
+    Source string `json:"source,omitempty"`
+    Author string `json:"author,omitempty"`
+}
+
+type Pack struct {
+    Title string `json:"title"`
+    Questions []Question `json:"questions"`
+}
+
+// fills in question numbers if the pack has none
+func (p *Pack) number() {
+    for i := range p.Questions {
+        if p.Questions[i].Number == 0 {
+            p.Questions[i].Number = i + 1
+        }
+    }
+}
+
+// a problem with a pack file, Line is 0 if it's about the whole file
+type Error struct {
+    File string
+    Line int
+    Msg string
+}
+
+func (e *Error) Error() string {
+    if e.Line == 0 {
+        return fmt.Sprintf("%s: %s", e.File, e.Msg)
+    }
+    return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Msg)
+}
+
+func errorAt(file string, line int, format string, args ...interface{}) *Error {
+    return &Error{File: file, Line: line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// the line the byte at offset is on
+func lineAt(data []byte, offset int64) int {
+    if offset > int64(len(data)) {
+        offset = int64(len(data))
+    }
+    return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+func (p *Pack) Validate() error {
+    _, err := p.invalid()
+    return err
+}
+
+// what is wrong with the pack and the index of the question it's about,
+// -1 if it's about the whole pack
+func (p *Pack) invalid() (int, error) {
+    if len(p.Questions) == 0 {
+        return -1, fmt.Errorf("pack '%s' has no questions", p.Title)
+    }
+    for i, q := range p.Questions {
+        if q.Text == "" {
+            return i, fmt.Errorf("question %d has no text", q.Number)
+        }
+        if q.Answer == "" {
+            return i, fmt.Errorf("question %d has no answer", q.Number)
+        }
+    }
+    return -1, nil
+}
+
+// the lines the questions of a json pack start on
+func questionLines(data []byte) []int {
+    var lines []int
+    var skip json.RawMessage
+    decoder := json.NewDecoder(bytes.NewReader(data))
+    if t, err := decoder.Token(); err != nil || t != json.Delim('{') {
+        return nil
+    }
+    for decoder.More() {
+        key, err := decoder.Token()
+        if err != nil {
+            return lines
+        }
+        if key != "questions" {
+            if decoder.Decode(&skip) != nil {
+                return lines
+            }
+            continue
+        }
+        if t, err := decoder.Token(); err != nil || t != json.Delim('[') {
+            return lines
+        }
+        for decoder.More() {
+            // the offset is right after the previous value
+            offset := decoder.InputOffset()
+            for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,", data[offset]) >= 0 {
+                offset++
+            }
+            lines = append(lines, lineAt(data, offset))
+            if decoder.Decode(&skip) != nil {
+                return lines
+            }
+        }
+        return lines
+    }
+    return lines
+}
+
+func LoadJSON(path string) (*Pack, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    p := &Pack{}
+    if err := json.Unmarshal(data, p); err != nil {
+        if syntax, ok := err.(*json.SyntaxError); ok {
+            return nil, errorAt(path, lineAt(data, syntax.Offset), "%s", err)
+        }
+        return nil, errorAt(path, 0, "%s", err)
+    }
+    p.number()
+    if i, err := p.invalid(); err != nil {
+        line := 0
+        if lines := questionLines(data); i >= 0 && i < len(lines) {
+            line = lines[i]
+        }
+        return nil, errorAt(path, line, "%s", err)
+    }
+    return p, nil
+}
+
+// picks the format by the extension: .txt of the quiz database, .siq of
+// SIGame and the pack's own json for anything else
+func Load(path string) (*Pack, error) {
+    switch strings.ToLower(filepath.Ext(path)) {
+    case ".txt":
+        return LoadDB(path)
+    case ".siq":
+        return LoadSIQ(path)
+    }
+    return LoadJSON(path)
+}
+
+func (p *Pack) WriteJSON(w io.Writer) error {
+    data, err := json.MarshalIndent(p, "", "    ")
+    if err != nil {
+        return err
+    }
+    _, err = w.Write(append(data, '\n'))
+    return err
+}
@@ -0,0 +1,184 @@
+package pack
+
+
+import (
+    "archive/zip"
+    "bytes"
+    "encoding/xml"
+    "fmt"
+    "io"
+    "path/filepath"
+    "strings"
+)
+
+// SIGame packages for Svoya igra: a zip with content.xml listing rounds,
+// their themes and the questions of every theme with prices. The theme
+// and the price go in front of the question text. Pictures and sounds
+// can't be shown in a terminal, their file names stand for them. What
+// comes after the marker of a question (version 4) or in its answer
+// parameter (version 5) is shown along with the answer, it goes to the
+// comment so that nobody sees it early
+
+type siqAtom struct {
+    Type string `xml:"type,attr"`
+    Text string `xml:",chardata"`
+}
+
+type siqQuestion struct {
+    Price int `xml:"price,attr"`
+    // version 4
+    Atoms []siqAtom `xml:"scenario>atom"`
+    // version 5
+    Params []struct {
+        Name string `xml:"name,attr"`
+        Items []siqAtom `xml:"item"`
+    } `xml:"params>param"`
+    Right []string `xml:"right>answer"`
+    Comments string `xml:"info>comments"`
+    Sources []string `xml:"info>sources>source"`
+    Authors []string `xml:"info>authors>author"`
+}
+
+func (a siqAtom) String() string {
+    text := strings.TrimSpace(a.Text)
+    switch a.Type {
+    case "", "text", "say":
+        return text
+    }
+    return fmt.Sprintf("[%s: %s]", a.Type, strings.TrimPrefix(text, "@"))
+}
+
+func joinAtoms(atoms []siqAtom) string {
+    var parts []string
+    for _, atom := range atoms {
+        if s := atom.String(); s != "" {
+            parts = append(parts, s)
+        }
+    }
+    return strings.Join(parts, " ")
+}
+
+// what is asked and what is shown with the answer
+func (q *siqQuestion) split() ([]siqAtom, []siqAtom) {
+    for _, param := range q.Params {
+        if param.Name == "question" {
+            for _, other := range q.Params {
+                if other.Name == "answer" {
+                    return param.Items, other.Items
+                }
+            }
+            return param.Items, nil
+        }
+    }
+    for i, atom := range q.Atoms {
+        if atom.Type == "marker" {
+            return q.Atoms[:i], q.Atoms[i + 1:]
+        }
+    }
+    return q.Atoms, nil
+}
+
+func trimAll(list []string) []string {
+    var trimmed []string
+    for _, s := range list {
+        if s = strings.TrimSpace(s); s != "" {
+            trimmed = append(trimmed, s)
+        }
+    }
+    return trimmed
+}
+
+func LoadSIQ(path string) (*Pack, error) {
+    archive, err := zip.OpenReader(path)
+    if err != nil {
+        return nil, errorAt(path, 0, "%s", err)
+    }
+    defer archive.Close()
+    for _, f := range archive.File {
+        if f.Name != "content.xml" {
+            continue
+        }
+        r, err := f.Open()
+        if err != nil {
+            return nil, errorAt(path, 0, "%s", err)
+        }
+        defer r.Close()
+        data, err := io.ReadAll(r)
+        if err != nil {
+            return nil, errorAt(path, 0, "%s", err)
+        }
+        p, err := parseSIQ(path + ":content.xml", data)
+        if err != nil {
+            return nil, err
+        }
+        if p.Title == "" {
+            p.Title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+        }
+        return p, nil
+    }
+    return nil, errorAt(path, 0, "no content.xml, not a SIGame package")
+}
+
+func parseSIQ(file string, data []byte) (*Pack, error) {
+    p := &Pack{}
+    theme := ""
+    decoder := xml.NewDecoder(bytes.NewReader(data))
+    for {
+        token, err := decoder.Token()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            if syntax, ok := err.(*xml.SyntaxError); ok {
+                return nil, errorAt(file, syntax.Line, "%s", syntax.Msg)
+            }
+            return nil, errorAt(file, lineAt(data, decoder.InputOffset()), "%s", err)
+        }
+        start, ok := token.(xml.StartElement)
+        if !ok {
+            continue
+        }
+        switch start.Name.Local {
+        case "package":
+            p.Title = attr(start, "name")
+        case "theme":
+            theme = attr(start, "name")
+        case "question":
+            line := lineAt(data, decoder.InputOffset())
+            var sq siqQuestion
+            if err := decoder.DecodeElement(&sq, &start); err != nil {
+                return nil, errorAt(file, line, "%s", err)
+            }
+            asked, shown := sq.split()
+            text := joinAtoms(asked)
+            if text == "" {
+                return nil, errorAt(file, line, "question has no text")
+            }
+            answers := trimAll(sq.Right)
+            if len(answers) == 0 {
+                return nil, errorAt(file, line, "question has no answer")
+            }
+            q := Question{Number: len(p.Questions) + 1,
+                          Text: fmt.Sprintf("%s, %d: %s", theme, sq.Price, text),
+                          Answer: answers[0],
+                          Accept: strings.Join(answers[1:], "; "),
+                          Source: strings.Join(trimAll(sq.Sources), "; "),
+                          Author: strings.Join(trimAll(sq.Authors), ", ")}
+            q.Comment = strings.Join(trimAll([]string{joinAtoms(shown), sq.Comments}), " ")
+            p.Questions = append(p.Questions, q)
+        }
+    }
+    if len(p.Questions) == 0 {
+        return nil, errorAt(file, 0, "no questions found")
+    }
+    return p, nil
+}
+
+func attr(start xml.StartElement, name string) string {
+    for _, a := range start.Attr {
+        if a.Name.Local == name {
+            return a.Value
+        }
+    }
+    return ""
+}
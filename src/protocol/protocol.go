@@ -0,0 +1,93 @@
+package protocol
+
+
+import (
+    "encoding/json"
+    "events"
+    "strings"
+)
+
+// lines starting with ControlPrefix are meant for the client program
+// and should never be shown to the player as is
+const ControlPrefix = "@@"
+
+// server -> client: "@@ping <token>", the client answers ":pong <token>"
+const Ping = ControlPrefix + "ping"
+const Pong = ":pong"
+
+// client -> server: ":events on" asks the server to send game events
+// as "@@event <json>" lines in addition to the usual text
+const Subscribe = ":events"
+const Event = ControlPrefix + "event"
+
+// server -> client: "@@roster <json list of players>", sent to event
+// subscribers in reply to ":who"
+const Roster = ControlPrefix + "roster"
+
+// server -> client: "@@session <token>", the token to come back with
+// after losing connection: ":session <token>"
+const Session = ControlPrefix + "session"
+const ResumeSession = ":session"
+
+// client -> server: ":press <ms>", a button press that happened that many
+// milliseconds before it was sent, bridges of hardware buttons date their
+// presses this way. The date is only taken from connections that have
+// said ":bridge <token>" with the server's bridge token
+const Press = ":press"
+const Bridge = ":bridge"
+
+type Player struct {
+    Name string `json:"name"`
+    // "master" or "player"
+    Role string `json:"role"`
+    Score int `json:"score"`
+}
+
+func IsControl(line string) bool {
+    return strings.HasPrefix(line, ControlPrefix)
+}
+
+func encode(prefix string, data interface{}) string {
+    encoded, err := json.Marshal(data)
+    if err != nil {
+        // our own types, should never happen
+        panic(err)
+    }
+    return prefix + " " + string(encoded) + "\n"
+}
+
+// returns the json part of "<prefix> <json>" line, ok is false if
+// the line has a different prefix
+func payload(prefix string, line string) (string, bool) {
+    line = strings.TrimRight(line, "\r\n")
+    if !strings.HasPrefix(line, prefix + " ") {
+        return "", false
+    }
+    return line[len(prefix) + 1:], true
+}
+
+func EncodeEvent(e events.Event) string {
+    return encode(Event, e)
+}
+
+func DecodeEvent(line string) (events.Event, bool) {
+    var e events.Event
+    data, ok := payload(Event, line)
+    if !ok || json.Unmarshal([]byte(data), &e) != nil {
+        return e, false
+    }
+    return e, true
+}
+
+func EncodeRoster(players []Player) string {
+    return encode(Roster, players)
+}
+
+func DecodeRoster(line string) ([]Player, bool) {
+    var players []Player
+    data, ok := payload(Roster, line)
+    if !ok || json.Unmarshal([]byte(data), &players) != nil {
+        return nil, false
+    }
+    return players, true
+}
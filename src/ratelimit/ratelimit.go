@@ -0,0 +1,48 @@
+package ratelimit
+
+
+import (
+    "sync"
+    "time"
+)
+
+// token bucket: holds up to burst tokens and gains rate tokens a second,
+// every allowed action takes one. A zero rate means no limit at all
+type Bucket struct {
+    mu sync.Mutex
+    rate float64
+    burst float64
+    tokens float64
+    last time.Time
+}
+
+// starts full
+func New(rate float64, burst int) *Bucket {
+    return &Bucket{rate: rate, burst: float64(burst), tokens: float64(burst)}
+}
+
+func (b *Bucket) Allow() bool {
+    return b.AllowAt(time.Now())
+}
+
+func (b *Bucket) AllowAt(now time.Time) bool {
+    if b.rate <= 0 {
+        return true
+    }
+    b.mu.Lock()
+    defer b.mu.Unlock()
+    if !b.last.IsZero() && now.After(b.last) {
+        b.tokens += now.Sub(b.last).Seconds() * b.rate
+        if b.tokens > b.burst {
+            b.tokens = b.burst
+        }
+    }
+    if b.last.IsZero() || now.After(b.last) {
+        b.last = now
+    }
+    if b.tokens < 1 {
+        return false
+    }
+    b.tokens--
+    return true
+}
@@ -0,0 +1,97 @@
+package rules
+
+
+import (
+    "fmt"
+    "i18n"
+    "server"
+    "time"
+)
+
+// house rules clubs play by, turned on with settings.Plugins:
+//   quickbonus within=3 points=1   right answers pressed within that many
+//                                  seconds of the countdown earn the bonus
+//   lastround factor=2 rounds=1    points of the last rounds are multiplied,
+//                                  if the match or the pack tells how many
+//                                  rounds there are
+
+func init() {
+    must(server.RegisterPlugin("quickbonus", newQuickBonus))
+    must(server.RegisterPlugin("lastround", newLastRound))
+    must(i18n.Add("en", "rules.quickbonus", "Quick answer bonus for %s: %s"))
+    must(i18n.Add("ru", "rules.quickbonus", "Бонус за быстрый ответ для %s: %s"))
+    must(i18n.Add("en", "rules.lastround", "Points of this round count %d times"))
+    must(i18n.Add("ru", "rules.lastround", "Очки этого раунда считаются %d раз", "Очки этого раунда считаются %d раза",
+                  "Очки этого раунда считаются %d раз"))
+}
+
+func must(err error) {
+    if err != nil {
+        panic(err)
+    }
+}
+
+type quickBonus struct {
+    server.NoHooks
+    within time.Duration
+    points int
+}
+
+func newQuickBonus(options server.Options) (server.Hooks, error) {
+    within, err := options.Int("within", 3)
+    if err != nil {
+        return nil, err
+    }
+    points, err := options.Int("points", 1)
+    if err != nil {
+        return nil, err
+    }
+    return &quickBonus{within: time.Duration(within) * time.Second, points: points}, nil
+}
+
+func (q *quickBonus) OnJudgement(game *server.Game, j *server.Judgement) {
+    if !j.Correct || j.PressedAfter < 0 || j.PressedAfter > q.within {
+        return
+    }
+    j.Points += q.points
+    game.Announce("rules.quickbonus", j.Player.GetName(), i18n.M("points", q.points))
+}
+
+type lastRound struct {
+    server.NoHooks
+    factor int
+    rounds int
+}
+
+func newLastRound(options server.Options) (server.Hooks, error) {
+    factor, err := options.Int("factor", 2)
+    if err != nil {
+        return nil, err
+    }
+    rounds, err := options.Int("rounds", 1)
+    if err != nil {
+        return nil, err
+    }
+    if rounds < 1 {
+        return nil, fmt.Errorf("rounds should be positive, not %d", rounds)
+    }
+    return &lastRound{factor: factor, rounds: rounds}, nil
+}
+
+func (l *lastRound) last(game *server.Game, round int) bool {
+    total := game.Rounds()
+    return total > 0 && round > total - l.rounds && round <= total
+}
+
+func (l *lastRound) OnJudgement(game *server.Game, j *server.Judgement) {
+    if l.last(game, game.Round()) {
+        j.Points *= l.factor
+    }
+}
+
+// tells the players once the last rounds begin
+func (l *lastRound) OnRoundEnd(game *server.Game, round int) {
+    if !l.last(game, round) && l.last(game, round + 1) {
+        game.Announce("rules.lastround", l.factor)
+    }
+}
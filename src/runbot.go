@@ -0,0 +1,36 @@
+package main
+
+import ("bot"
+        "flag"
+        "fmt"
+        "net"
+        "os"
+        "settings"
+        "strconv")
+
+
+func main(){
+    cfg := bot.DefaultConfig()
+    flag.StringVar(&settings.SERVER, "server", settings.SERVER, "server address")
+    flag.IntVar(&settings.PORT, "port", settings.PORT, "server port")
+    flag.IntVar(&cfg.Players, "players", cfg.Players, "number of simulated players")
+    flag.IntVar(&cfg.Rounds, "rounds", cfg.Rounds, "rounds the master bot plays")
+    flag.IntVar(&cfg.RoundSeconds, "round-seconds", cfg.RoundSeconds, "countdown of a round")
+    flag.DurationVar(&cfg.PressDelayMin, "press-min", cfg.PressDelayMin,
+                     "earliest press after the countdown starts")
+    flag.DurationVar(&cfg.PressDelayMax, "press-max", cfg.PressDelayMax,
+                     "latest press after the countdown starts")
+    flag.Float64Var(&cfg.ChatProbability, "chat", cfg.ChatProbability,
+                    "chance a player chats after joining")
+    flag.Float64Var(&cfg.AcceptProbability, "accept", cfg.AcceptProbability,
+                    "chance the master accepts an answer")
+    flag.DurationVar(&cfg.JoinInterval, "join-interval", cfg.JoinInterval,
+                     "pause between players joining")
+    flag.Parse()
+    cfg.Addr = net.JoinHostPort(settings.SERVER, strconv.Itoa(settings.PORT))
+    stats := bot.Run(cfg)
+    fmt.Print(stats)
+    if stats.Errors() > 0 {
+        os.Exit(1)
+    }
+}
@@ -0,0 +1,36 @@
+package main
+
+import ("client"
+        "flag"
+        "fmt"
+        "os"
+        "settings")
+
+
+func main(){
+    flag.StringVar(&settings.SERVER, "server", settings.SERVER, "server address")
+    flag.IntVar(&settings.PORT, "port", settings.PORT, "server port")
+    device := flag.String("device", "-", "serial device the buttons are read from, - for the standard input")
+    baud := flag.Int("baud", 9600, "baud rate of the serial device, 0 keeps it as it is")
+    teams := flag.String("teams", "", "buttons of the teams, e.g. 1=Alpha,2=Beta")
+    room := flag.String("room", "", "room the teams play in")
+    token := flag.String("token", "", "the server's bridge token, presses are not dated without it")
+    flag.Parse()
+    buttons, err := client.ParseTeams(*teams)
+    if err != nil {
+        fmt.Fprintf(os.Stderr, "-teams: %s\n", err)
+        os.Exit(2)
+    }
+    input, err := client.OpenInput(*device, *baud)
+    if err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+    defer input.Close()
+    bridge := client.NewBridge(settings.SERVER, settings.PORT, buttons, *room, *token, os.Stdout)
+    defer bridge.Close()
+    if err := bridge.Run(input); err != nil {
+        fmt.Fprintln(os.Stderr, err)
+        os.Exit(1)
+    }
+}
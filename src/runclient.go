@@ -1,9 +1,31 @@
 package main
 
 import ("client"
+        "flag"
+        "fmt"
+        "os"
         "settings")
 
 
 func main(){
-    client.StartClient(settings.SERVER, settings.PORT)
+    var opts client.Options
+    flag.StringVar(&settings.SERVER, "server", settings.SERVER, "server address")
+    flag.IntVar(&settings.PORT, "port", settings.PORT, "server port")
+    flag.BoolVar(&opts.Plain, "plain", false,
+                 "line mode: no full screen interface even in a terminal")
+    flag.BoolVar(&opts.Master, "master", false,
+                 "master console: become the master, judge with hotkeys")
+    flag.BoolVar(&opts.Reconnect, "reconnect", true,
+                 "reconnect and resume the session when the connection is lost")
+    offline := flag.String("offline-input", "hold",
+                           "what to do with input typed while offline: hold or discard")
+    flag.StringVar(&opts.Sound, "sound", "",
+                   "command playing cues, run without a shell, %s is the cue name; empty rings the bell, off keeps quiet")
+    flag.Parse()
+    if *offline != "hold" && *offline != "discard" {
+        fmt.Fprintf(os.Stderr, "-offline-input: hold or discard, not '%s'\n", *offline)
+        os.Exit(2)
+    }
+    opts.HoldInput = *offline == "hold"
+    client.StartClient(settings.SERVER, settings.PORT, opts)
 }
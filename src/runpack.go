@@ -0,0 +1,58 @@
+package main
+
+import ("flag"
+        "fmt"
+        "os"
+        "pack")
+
+
+// checks question packs and converts them to the server's json:
+//   runpack pack.txt other.siq      tells what is in them or what's wrong
+//   runpack -o pack.json pack.siq   converts, "-o -" writes to stdout
+func main(){
+    out := flag.String("o", "", "convert the pack to json and write it there, - for stdout")
+    list := flag.Bool("list", false, "list the questions with their answers")
+    flag.Parse()
+    if flag.NArg() == 0 || *out != "" && flag.NArg() != 1 {
+        fmt.Fprintln(os.Stderr, "usage: runpack [-list] pack... | runpack -o out.json pack")
+        os.Exit(2)
+    }
+    status := 0
+    for _, path := range flag.Args() {
+        p, err := pack.Load(path)
+        if err != nil {
+            fmt.Fprintln(os.Stderr, err)
+            status = 1
+            continue
+        }
+        if *out != "" {
+            if err := convert(p, *out); err != nil {
+                fmt.Fprintln(os.Stderr, err)
+                os.Exit(1)
+            }
+            continue
+        }
+        fmt.Printf("%s: '%s', %d questions\n", path, p.Title, len(p.Questions))
+        if *list {
+            for _, q := range p.Questions {
+                fmt.Printf("  %d. %s\n     %s\n", q.Number, q.Text, q.Answer)
+            }
+        }
+    }
+    os.Exit(status)
+}
+
+func convert(p *pack.Pack, path string) error {
+    if path == "-" {
+        return p.WriteJSON(os.Stdout)
+    }
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    if err := p.WriteJSON(f); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
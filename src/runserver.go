@@ -1,10 +1,68 @@
 package main
 
 
-import ("server"
-        "settings")
+import ("admin"
+        "display"
+        "flag"
+        "io"
+        "logger"
+        "os"
+        _ "rules"
+        "server"
+        "settings"
+        "strings"
+        "utils")
+
+func setupLogging() {
+    level, err := logger.ParseLevel(settings.LogLevel)
+    utils.ProcError(err)
+    var out io.Writer = os.Stdout
+    if settings.LogFile != "" {
+        out, err = logger.OpenRotating(settings.LogFile, settings.LogMaxSize, settings.LogBackups)
+        utils.ProcError(err)
+    }
+    logger.SetDefault(logger.New(out, level, settings.LogFormat == "json"))
+}
 
 func main() {
-    s := server.NewServer(settings.SERVER, settings.PORT, nil)
+    flag.StringVar(&settings.ADMIN, "admin", settings.ADMIN,
+                   "address of the HTTP admin API, empty to disable")
+    flag.StringVar(&settings.AdminToken, "admin-token", settings.AdminToken,
+                   "bearer token required by the admin API")
+    flag.StringVar(&settings.BridgeToken, "bridge-token", settings.BridgeToken,
+                   "token button bridges connect with, empty to refuse them")
+    flag.DurationVar(&settings.PressWindow, "press-window", settings.PressWindow,
+                     "presses within that of the first one are ordered by the bridges' dates")
+    flag.StringVar(&settings.DISPLAY, "display", settings.DISPLAY,
+                   "address of the big screen pages, /display/<room>, empty to disable")
+    flag.StringVar(&settings.LogLevel, "log-level", settings.LogLevel,
+                   "debug, info, warn or error")
+    flag.StringVar(&settings.LogFormat, "log-format", settings.LogFormat, "text or json")
+    flag.StringVar(&settings.LogFile, "log-file", settings.LogFile,
+                   "write log to this file instead of stdout")
+    flag.Int64Var(&settings.LogMaxSize, "log-max-size", settings.LogMaxSize,
+                   "rotate the log file when it grows over that many bytes")
+    flag.IntVar(&settings.LogBackups, "log-backups", settings.LogBackups,
+                "rotated log files to keep")
+    flag.StringVar(&settings.FalseStart, "false-start", settings.FalseStart,
+                   "false start rule: lockout, award, penalty [points] or grace [ms]")
+    flag.StringVar(&settings.Plugins, "plugins", settings.Plugins,
+                   "house rules, e.g. \"quickbonus within=3; lastround factor=2\"")
+    flag.StringVar(&settings.PluginFiles, "plugin-files", settings.PluginFiles,
+                   "Go plugins to open, separated by spaces")
+    flag.Parse()
+    setupLogging()
+    utils.ProcError(server.OpenPlugins(strings.Fields(settings.PluginFiles)))
+    s := server.NewServer(settings.SERVER, settings.PORT)
+    if settings.ADMIN != "" {
+        go func() {
+            utils.ProcError(admin.New(s, settings.AdminToken).ListenAndServe(settings.ADMIN))
+        }()
+    }
+    if settings.DISPLAY != "" {
+        go func() {
+            utils.ProcError(display.New(s).ListenAndServe(settings.DISPLAY))
+        }()
+    }
     s.Start()
 }
@@ -0,0 +1,254 @@
+package server
+
+
+import (
+    "encoding/json"
+    "i18n"
+    "io/ioutil"
+    "net"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+// banned addresses and names, kept in settings.BanFile across restarts.
+// Connections from banned addresses are closed before they reach a room,
+// banned names cannot be taken
+
+type Ban struct {
+    // an ip address or a player name
+    Value string `json:"value"`
+    IsName bool `json:"is_name,omitempty"`
+    // the master who has banned
+    By string `json:"by,omitempty"`
+    // zero for a permanent ban
+    Until time.Time `json:"until,omitempty"`
+}
+
+func (ban Ban) expired() bool {
+    return !ban.Until.IsZero() && time.Now().After(ban.Until)
+}
+
+// names are compared ignoring the letter case, the way names are told apart
+func (ban Ban) matches(value string, isName bool) bool {
+    if ban.IsName != isName {
+        return false
+    }
+    if isName {
+        return strings.EqualFold(ban.Value, value)
+    }
+    return ban.Value == value
+}
+
+func (ban Ban) message() *i18n.Message {
+    id := "ban.entry.address"
+    if ban.IsName {
+        id = "ban.entry.name"
+    }
+    until, by := i18n.Text(""), i18n.Text("")
+    if !ban.Until.IsZero() {
+        until = i18n.M("ban.until", ban.Until.Format("2006-01-02 15:04:05"))
+    }
+    if ban.By != "" {
+        by = i18n.M("ban.by", ban.By)
+    }
+    return i18n.M(id, ban.Value, until, by)
+}
+
+func (ban Ban) String() string {
+    return ban.message().String()
+}
+
+type banList struct {
+    mu sync.Mutex
+    // empty to keep bans in memory only
+    path string
+    bans []Ban
+}
+
+func loadBans(path string) (*banList, error) {
+    list := &banList{path: path}
+    if path == "" {
+        return list, nil
+    }
+    data, err := ioutil.ReadFile(path)
+    if os.IsNotExist(err) {
+        return list, nil
+    }
+    if err != nil {
+        return list, err
+    }
+    return list, json.Unmarshal(data, &list.bans)
+}
+
+// writes to a temporary file first so that a crash can't leave half a list
+func (list *banList) save() error {
+    if list.path == "" {
+        return nil
+    }
+    data, err := json.MarshalIndent(list.bans, "", "  ")
+    if err != nil {
+        return err
+    }
+    tmp := list.path + ".tmp"
+    if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+        return err
+    }
+    return os.Rename(tmp, list.path)
+}
+
+// drops expired bans, the caller holds mu
+func (list *banList) purge() {
+    var active []Ban
+    for _, ban := range list.bans {
+        if !ban.expired() {
+            active = append(active, ban)
+        }
+    }
+    list.bans = active
+}
+
+func (list *banList) add(bans ...Ban) error {
+    list.mu.Lock()
+    defer list.mu.Unlock()
+    list.purge()
+    for _, ban := range bans {
+        list.removeLocked(ban.Value, ban.IsName)
+        list.bans = append(list.bans, ban)
+    }
+    return list.save()
+}
+
+func (list *banList) removeLocked(value string, isName bool) bool {
+    for i, ban := range list.bans {
+        if ban.matches(value, isName) {
+            list.bans = append(list.bans[:i], list.bans[i+1:]...)
+            return true
+        }
+    }
+    return false
+}
+
+// false if there's no such ban
+func (list *banList) remove(value string, isName bool) (bool, error) {
+    list.mu.Lock()
+    defer list.mu.Unlock()
+    list.purge()
+    if !list.removeLocked(value, isName) {
+        return false, nil
+    }
+    return true, list.save()
+}
+
+func (list *banList) find(value string, isName bool) *Ban {
+    list.mu.Lock()
+    defer list.mu.Unlock()
+    for _, ban := range list.bans {
+        if ban.matches(value, isName) && !ban.expired() {
+            return &ban
+        }
+    }
+    return nil
+}
+
+func (list *banList) all() []Ban {
+    list.mu.Lock()
+    defer list.mu.Unlock()
+    list.purge()
+    return append([]Ban(nil), list.bans...)
+}
+
+func hostOf(addr net.Addr) string {
+    host, _, err := net.SplitHostPort(addr.String())
+    if err != nil {
+        return addr.String()
+    }
+    return host
+}
+
+// ":ban <name|#id|ip> [duration]", duration as in 30m or 2h, forever if not
+// given. A player's name is banned, not the address: teams at a venue or
+// behind a bridge share one
+func (game *Game) procBanCmd(cmdParts []string, client *Client) {
+    args := cmdParts[1:]
+    var until time.Time
+    if len(args) > 1 {
+        if d, err := time.ParseDuration(args[len(args) - 1]); err == nil && d > 0 {
+            until = time.Now().Add(d)
+            args = args[:len(args) - 1]
+        }
+    }
+    ref := strings.Join(args, " ")
+    bans := game.server.bans
+    if ip := net.ParseIP(ref); ip != nil {
+        if err := bans.add(Ban{Value: ip.String(), By: client.name, Until: until}); err != nil {
+            game.log.Error("cannot save bans", "err", err)
+        }
+        game.Notify(client, "ban.address.done", ip)
+        for _, cl := range game.GetClientsOnline() {
+            if hostOf(cl.conn.RemoteAddr()) == ip.String() && cl != client {
+                game.kick(cl, i18n.M("ban.banned"))
+            }
+        }
+        return
+    }
+    target, err := game.findClient(ref)
+    if err != nil {
+        game.Notify(client, "find.failed", ref, err)
+        return
+    }
+    if target == client {
+        game.Notify(client, "ban.self")
+        return
+    }
+    err = bans.add(Ban{Value: target.name, IsName: true, By: client.name, Until: until})
+    if err != nil {
+        game.log.Error("cannot save bans", "err", err)
+    }
+    game.kick(target, i18n.M("ban.banned"))
+}
+
+// ":unban <name|ip>"
+func (game *Game) procUnbanCmd(cmdParts []string, client *Client) {
+    value := strings.Join(cmdParts[1:], " ")
+    isName := true
+    if ip := net.ParseIP(value); ip != nil {
+        value, isName = ip.String(), false
+    }
+    found, err := game.server.bans.remove(value, isName)
+    if err != nil {
+        game.log.Error("cannot save bans", "err", err)
+    }
+    if !found {
+        game.Notify(client, "ban.not_banned", value)
+        return
+    }
+    game.Notify(client, "ban.lifted", value)
+}
+
+func (game *Game) procBansCmd(client *Client) {
+    bans := game.server.bans.all()
+    if len(bans) == 0 {
+        game.Notify(client, "ban.none")
+        return
+    }
+    for _, ban := range bans {
+        game.say(ban.message(), client)
+    }
+}
+
+// ":kick <name|#id>"
+func (game *Game) procKickCmd(cmdParts []string, client *Client) {
+    ref := strings.Join(cmdParts[1:], " ")
+    target, err := game.findClient(ref)
+    if err != nil {
+        game.Notify(client, "find.failed", ref, err)
+        return
+    }
+    if target == client {
+        game.Notify(client, "kick.self")
+        return
+    }
+    game.kick(target, i18n.M("reason.by", client.name))
+}
@@ -3,36 +3,85 @@ package server
 
 import (
     "bufio"
+    "errors"
+    "events"
     "io"
     "fmt"
+    "i18n"
     "listener"
+    "logger"
+    "metrics"
     "net"
+    "pack"
+    "protocol"
     "settings"
     "strconv"
     "strings"
     "sync"
+    "sync/atomic"
     "syscall"
     "time"
+    "tournament"
     "utils"
 )
 
 type Client struct {
-    // a reference to game played
+    // a reference to game played, set with setRoom
     Game *Game
+    // unique within the server, names are not
+    id int
     name string
-    incoming chan string
+    incoming chan input
     outcoming chan string
     reader *bufio.Reader
     writer *bufio.Writer
+    // set with setMaster
     isMaster bool
+    // the room and the role for goroutines outside of the game loop
+    view atomic.Value
     canAnswer bool
+    score int
+    // last measured round trip time, see Ping
+    rtt time.Duration
+    // last time the player typed anything
+    lastActivity time.Time
     // FIXME probably will needed to determine button click
     // precedence regardless of race conditions
     pressTime time.Time
+    // of the last press taken, see Hooks.OnPress
+    pressedAfter time.Duration
+    // a bridge of hardware buttons, it dates its presses, see bridges.go
+    bridge bool
     // XXX FIXME Do we need to close it manually?
     conn net.Conn
     // if true then already cleaned up
     disconnected bool
+    disconnectedAt time.Time
+    // closed on cleanup, for the goroutines outside of the game loop
+    gone chan bool
+    goneOnce sync.Once
+    // lets the player come back after losing connection, see procSessionCmd
+    token string
+    // game events subscription, see procEventsCmd
+    feed *events.Subscription
+    // flood protection, see flood.go
+    limits limits
+    // a warning about dropped lines has been sent already
+    throttled bool
+    muted bool
+    // zero if muted until unmuted
+    mutedUntil time.Time
+    // language of server messages, see lang.go
+    lang string
+    // players of the same team share a channel, see channels.go
+    team string
+    // the team the player has asked to join, until a teammate accepts
+    wantsTeam string
+    // history of the room: the first entry replayed on coming in and the
+    // last one seen before losing connection, see history.go
+    replayedFrom int
+    lastSeen int
+    log *logger.Logger
 }
 
 func (client *Client) GetName() string {
@@ -42,36 +91,140 @@ func (client *Client) GetName() string {
     return client.name
 }
 
+// what goroutines outside of the game loop go by, e.g. to tell whether
+// the client may see private events
+type clientView struct {
+    game *Game
+    master bool
+}
+
+func (client *Client) setRoom(game *Game) {
+    client.Game = game
+    client.view.Store(clientView{game, client.isMaster})
+}
+
+func (client *Client) setMaster(master bool) {
+    client.isMaster = master
+    client.view.Store(clientView{client.Game, master})
+}
+
+// safe to call from any goroutine
+func (client *Client) loadView() clientView {
+    view, _ := client.view.Load().(clientView)
+    return view
+}
+
 func (client *Client) SetName(name string) {
     client.name = name
 }
 
 func (client *Client) Read() {
-    game := client.Game
     for {
-        line, err := client.reader.ReadString(settings.EOL)
-        oe, ok := err.(*net.OpError)
-        if err == io.EOF || ok && oe.Err == syscall.ECONNRESET {
-            game.SystemMsg(
-                fmt.Sprintf("Client %s disconnected", client.conn.RemoteAddr()), true)
-            client.Exit()
+        line, tooLong, err := client.readLine()
+        if err == io.EOF || errors.Is(err, syscall.ECONNRESET) {
+            // the client may have moved to another room meanwhile
+            game := client.Game
+            // fails if the room has been shut down, the client is gone then
+            game.Do(func() {
+                game.SystemMsg(
+                    fmt.Sprintf("Client %s disconnected", client.conn.RemoteAddr()), true)
+                client.Exit()
+                if game.master == client {
+                    game.masterLost(client)
+                }
+                game.publish(events.Leave, client.name, "", client.conn.RemoteAddr().String())
+            })
             return
-        } else if err != nil && client.disconnected {
+        } else if err != nil && client.isGone() {
             // XXX FIXME this read should not occur at all!!!
-            game.SystemMsg("WARN: reading from a disconnected client", false)
+            client.log.Warn("reading from a disconnected client")
             return
         }
         utils.ProcError(err)
-        client.incoming <- line
+        if tooLong {
+            // judged in the room loop, the line itself is dropped
+            client.incoming <- input{tooLong: true}
+            continue
+        }
+        if strings.HasPrefix(line, protocol.Pong) {
+            client.procPong(line)
+            continue
+        }
+        in := input{text: line}
+        if _, ok := pressAge(line); ok || line == string(settings.EOL) {
+            in.at = time.Now()
+        }
+        client.incoming <- in
+    }
+}
+
+// sends a ping every interval to measure round trip time
+func (client *Client) Ping(interval time.Duration) {
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+    for {
+        select {
+        case <-client.gone:
+            return
+        case <-ticker.C:
+        }
+        client.send(fmt.Sprintf("%s %d%c", protocol.Ping, time.Now().UnixNano(), settings.EOL))
+    }
+}
+
+func (client *Client) procPong(line string) {
+    parts := sanitizeCommandString(line)
+    if len(parts) != 2 {
+        return
+    }
+    sent, err := strconv.ParseInt(parts[1], 10, 64)
+    if err != nil {
+        return
+    }
+    // measured here, the loop may be busy
+    rtt := time.Since(time.Unix(0, sent))
+    game := client.loadView().game
+    if game == nil {
+        return
+    }
+    game.server.metrics.rtt.With(game.Name).Observe(rtt.Seconds())
+    game.Do(func() { client.rtt = rtt })
+}
+
+// queues data for the client, drops it if the client can't keep up
+func (client *Client) send(data string) bool {
+    if client.isGone() {
+        return false
+    }
+    select {
+    case client.outcoming <- data:
+        return true
+    default:
+        if game := client.loadView().game; game != nil {
+            game.server.metrics.dropped.With(game.Name).Inc()
+        }
+        return false
+    }
+}
+
+// true once cleaned up, unlike disconnected safe to ask from any goroutine
+func (client *Client) isGone() bool {
+    select {
+    case <-client.gone:
+        return true
+    default:
+        return false
     }
 }
 
 func (client *Client) Write() {
     for data := range client.outcoming {
+        if client.isGone() {
+            return
+        }
         _, err := client.writer.WriteString(data)
         if err != nil {
-            fmt.Println("aaaaa")
-            fmt.Println(err)
+            client.log.Error("write failed", "err", err)
         }
         client.writer.Flush()
     }
@@ -80,49 +233,101 @@ func (client *Client) Write() {
 func (client *Client) Listen() {
     go client.Read()
     go client.Write()
+    go client.Ping(settings.PingInterval)
 }
 
 func (client *Client) Exit() {
     defer func() {
         client.disconnected = true
+        client.disconnectedAt = time.Now()
+        client.goneOnce.Do(func() { close(client.gone) })
         client.conn.Close()
     }()
 
-    game := client.Game
-    if game.master == client {
-        game.master = nil
-    }
+    client.lastSeen = client.Game.historySeq
+    client.unsubscribe()
 }
 
-func NewClient(conn net.Conn, name string) *Client {
+func NewClient(conn net.Conn, id int, name string) *Client {
     reader := bufio.NewReader(conn)
     writer := bufio.NewWriter(conn)
-    client := &Client{name: name,
+    client := &Client{id: id,
+                     name: name,
                      reader: reader,
                      writer: writer,
-                     incoming: make(chan string),
-                     outcoming: make(chan string),
+                     incoming: make(chan input),
+                     outcoming: make(chan string, settings.SendQueueSize),
+                     gone: make(chan bool),
                      canAnswer: true,
-                     conn: conn}
-    client.Listen()
+                     lang: settings.Language,
+                     limits: newLimits(),
+                     lastActivity: time.Now(),
+                     conn: conn,
+                     log: logger.Default().With("client", id, "addr", conn.RemoteAddr())}
     return client
 }
 
 type Game struct {
+    // room name, used to tell events of different games apart
+    Name string
     Clients []*Client
     joins chan net.Conn
-    incoming chan string
-    timeout chan time.Time
+    // carries timerGen of the countdown that has run out
+    timeout chan int
     master *Client
+    // rights of co-masters by client id, see masters.go
+    coMasters map[int]rights
+    // the master has turned team chat off
+    noTeamChat bool
+    // the last broadcasts, see history.go
+    history []historyEntry
+    historySeq int
     buttonPressed *Client
+    // presses waiting for settings.PressWindow to be over, see contend
+    contenders []*Client
+    pressGen int
+    // the last one who answered, to be judged by master
+    lastAnswered *Client
     // when true any button click prior to time=true
     // means false start
     gameMode bool
     // true if countdown has started
     time bool
+    // when the countdown ends
+    deadline time.Time
+    timer *time.Timer
+    // gives the warning cue, see settings.CueWarning
+    warningTimer *time.Timer
+    timerGen int
+    // when the countdown has been started or resumed
+    timerStarted time.Time
+    // countdown is on hold, remaining is what's left of it
+    paused bool
+    remaining time.Duration
+    // question pack and index of the current question in it
+    pack *pack.Pack
+    question int
+    // the master has revealed its answer
+    revealed bool
+    // the match being played, nil if none
+    match *match
+    // rounds over so far, see Round, and the plugins' hooks
+    round int
+    hooks []Hooks
+    falseStart falseStartPolicy
+    // master's commands to take back and the ones taken back, see undo.go
+    undo []undoEntry
+    redo []undoEntry
+    // bumped when the stacks are forgotten
+    undoGen int
     // notify when client wants to exit
     exit chan bool
+    // closed once the game loop has finished
+    done chan bool
+    // functions to be run inside the game loop, see Do
+    actions chan func()
     server *Server
+    log *logger.Logger
 }
 
 func (game *Game) GetClientsOnline() []*Client {
@@ -136,45 +341,134 @@ func (game *Game) GetClientsOnline() []*Client {
 }
 
 func (game *Game) SystemMsg(data string, notify bool) {
-    fmt.Println(data)
+    game.log.Info(data)
     if notify {
-        game.notifyListener(fmt.Sprintf("(system) %s", data))
+        game.publish(events.System, "", "", data)
     }
 }
 
+// sends text that needs no translation, chat lines for instance, see
+// Announce for server messages
 func (game *Game) Broadcast(data string) {
-    // if data doesn't end in EOL, add one
-    if !strings.HasSuffix(data, string(settings.EOL)) {
-        data = data + string(settings.EOL)
-    }
-    for _, client := range game.GetClientsOnline() {
-        client.outcoming <- data
-    }
-    game.notifyListener(fmt.Sprintf("(broadcast) %s", data))
+    game.announce(i18n.Text(strings.TrimSuffix(data, string(settings.EOL))))
 }
 
+// same as Broadcast for a single client, see Notify
 func (game *Game) Inform(data string, client *Client) {
-    // if data doesn't end in EOL, add one
-    if !strings.HasSuffix(data, string(settings.EOL)) {
-        data = data + string(settings.EOL)
-    }
-    client.outcoming <- data
-    game.notifyListener(fmt.Sprintf("(whisper) %s", data))
+    game.say(i18n.Text(strings.TrimSuffix(data, string(settings.EOL))), client)
 }
 
 // makes all clients be able to answer again
 func (game *Game) Reset() {
     game.gameMode = true
+    game.stopTimer()
     game.time = false
+    game.paused = false
+    game.deadline = time.Time{}
     game.buttonPressed = nil
+    game.lastAnswered = nil
+    game.contenders = nil
+    game.pressGen++
     for _, client := range game.GetClientsOnline() {
-        client.canAnswer = true
+        // only the teams of the match may press
+        client.canAnswer = game.match == nil || game.match.plays(client)
     }
 }
 
 func (game *Game) SetMaster(client *Client) {
     game.master = client
-    client.isMaster = true
+    client.setMaster(true)
+}
+
+func (game *Game) takeMaster(client *Client) error {
+    // a master who has lost connection keeps the seat until masterFailover
+    if game.master != nil && client != game.master {
+        game.SystemMsg(fmt.Sprintf("%s attempted to seize the crown!", client.GetName()), false)
+        return ErrHasMaster
+    }
+    game.crown(client, "")
+    game.Announce("master.new", client.GetName())
+    return nil
+}
+
+func (game *Game) dropMaster() {
+    if game.master == nil {
+        return
+    }
+    master := game.master
+    master.setMaster(false)
+    game.master = nil
+    game.publish(events.Master, "", master.name, "revoked")
+    game.Announce("master.gone", master.GetName())
+}
+
+func (game *Game) rename(client *Client, newName string) error {
+    newName, err := game.checkName(newName, client)
+    if err != nil {
+        return err
+    }
+    oldName := client.GetName()
+    game.publish(events.Rename, client.name, newName, newName)
+    client.name = newName
+    game.Announce("rename.done", oldName, newName)
+    // a tournament team may have just come
+    go game.server.scheduleMatches()
+    return nil
+}
+
+// reason may be nil
+func (game *Game) kick(client *Client, reason *i18n.Message) {
+    if reason != nil {
+        game.Notify(client, "kick.you.reason", reason)
+    } else {
+        game.Notify(client, "kick.you")
+    }
+    game.publish(events.Leave, client.name, "", "kicked")
+    // no coming back
+    client.token = ""
+    client.Exit()
+    game.Announce("kick.done", client.GetName())
+}
+
+// master's verdict on the last answer
+func (game *Game) judge(client *Client, correct bool, points int) {
+    answered := game.lastAnswered
+    if answered == nil {
+        game.Notify(client, "judge.nothing")
+        return
+    }
+    // a wrong answer costs nothing unless house rules say otherwise
+    j := &Judgement{Player: answered, Correct: correct, PressedAfter: answered.pressedAfter}
+    if correct {
+        j.Points = points
+    }
+    game.runHooks(func(h Hooks) { h.OnJudgement(game, j) })
+    answered.score += j.Points
+    if correct {
+        game.publish(events.Judgement, client.name, answered.name, "accept")
+        game.Announce("judge.right", answered.GetName(), answered.score)
+        game.matchJudged(answered, j.Points)
+        game.Reset()
+        game.roundOver()
+    } else {
+        game.publish(events.Judgement, client.name, answered.name, "reject")
+        game.Announce("judge.wrong", answered.GetName())
+        game.matchJudged(answered, j.Points)
+        game.lastAnswered = nil
+    }
+}
+
+// the client is the one to answer
+func (game *Game) takePress(client *Client) {
+    game.buttonPressed = client
+    client.pressedAfter = client.pressTime.Sub(game.timerStarted)
+    game.publish(events.Press, client.name, "", "")
+    game.cue(events.CuePress, client)
+    game.Announce("press.answer", game.buttonPressed.GetName())
+    game.runHooks(func(h Hooks) { h.OnPress(game, client, client.pressedAfter) })
+    game.server.metrics.presses.With(game.Name).Inc()
+    game.server.metrics.pressLatency.With(game.Name).Observe(
+        time.Since(client.pressTime).Seconds())
 }
 
 // return an array of token strings
@@ -190,197 +484,189 @@ func sanitizeCommandString(cmd string) []string {
     return cmdParts
 }
 
-func (game *Game) procTimeCmd(cmdParts []string, client *Client) {
-    var seconds int
-    var err error
-    if len(cmdParts) > 1 {
-        seconds, err = strconv.Atoi(cmdParts[1])
-        if err != nil {
-            game.Inform(fmt.Sprintf(
-                "Argument of time should be an integer, not '%s'", cmdParts[1]), client)
+func (game *Game) procTimeCmd(seconds int, client *Client) {
+    game.buttonPressed = nil
+    game.startTimer(time.Duration(seconds) * time.Second)
+    game.publish(events.TimerStart, client.name, "", strconv.Itoa(seconds))
+    game.cue(events.CueStart, nil)
+    game.Announce("timer.seconds", seconds)
+}
+
+// a line read from the client, on its way to the game loop
+type input struct {
+    text string
+    // over settings.MaxLineLength, there's no text then
+    tooLong bool
+    // when a press has been read, zero for other lines
+    at time.Time
+}
+
+// the client's lines go to the room it is in at the moment and are dealt
+// with inside its loop, one at a time
+func (client *Client) procEventLoop() {
+    for {
+        in := <- client.incoming
+        game := client.Game
+        game.Do(func() {
+            if in.tooLong {
+                client.violation(i18n.M("flood.too_long", settings.MaxLineLength))
                 return
-        }
-    } else {
-        seconds = settings.RoundTimeout
+            }
+            client.lastActivity = time.Now()
+            if !in.at.IsZero() {
+                client.pressTime = in.at
+            }
+            game.procLine(in.text, client)
+        })
     }
-    if !game.gameMode {
-        game.Inform("Enter game mode first!", client)
-        return
+}
+
+func (game *Game) procLine(data string, client *Client) {
+    if age, ok := pressAge(data); ok {
+        // only bridges may say when their buttons have been pressed
+        if client.bridge {
+            client.pressTime = client.pressTime.Add(-age)
+        }
+        data = string(settings.EOL)
     }
-    if game.master != client {
-        game.Inform("Only master can launch countdown!", client)
+    if !client.allow(data) {
         return
     }
-    game.buttonPressed = nil
-    game.time = true
-    go func() {
-        game.timeout <- <- time.After(
-            time.Duration(seconds) * time.Second)
-        }()
-    game.Broadcast(fmt.Sprintf("===========%d seconds===========", seconds))
-}
-
-func (game *Game) ProcessCommand(cmd string, client *Client) {
-    cmdParts := sanitizeCommandString(cmd)
-    if cmdParts[0] == ":rename" && len(cmdParts) == 2 {
-        newName := strings.Join(cmdParts[1:len(cmdParts)], " ")
-        oldName := client.GetName()
-        client.name = newName
-        game.Broadcast(fmt.Sprintf("%s is now known as %s", oldName, newName))
-    } else if cmdParts[0] == ":master" {
-        if game.master != nil && client != game.master {
-            // FIXME ping master first, make sure it exists
-            game.SystemMsg(fmt.Sprintf("%s attempted to seize the crown!", client.GetName()), false)
-            game.Inform("The game has a master already", client)
+    if strings.HasPrefix(data, ":") {
+        game.ProcessCommand(data, client)
+    } else if data == "\n" {
+        /* special case: in game mode ENTER press means button click
+           a click prior :time command is considered as a false start
+        */
+        if !game.gameMode {
+            // do not send empty messages when chatting, that's not polite!
             return
         }
-        game.SetMaster(client)
-        game.Broadcast(fmt.Sprintf("%s is now the master of the game", client.GetName()))
-    }  else if cmdParts[0] == ":time" {
-        game.procTimeCmd(cmdParts, client)
-    } else if cmdParts[0] == ":reset" {
-        if game.master != client {
-            game.Inform("Only master can reset the game!", client)
-            return
+        if client.canAnswer && game.buttonPressed != nil && client != game.buttonPressed {
+            // the master may want to know who was next
+            game.publish(events.LatePress, client.name, "", "")
         }
-        if !game.gameMode {
-            game.Inform("Enter game mode first!", client)
+        if !client.canAnswer || client != game.buttonPressed && game.buttonPressed != nil {
+            game.refuse(client, "press.cannot")
             return
         }
-        game.Reset()
-        game.Inform("======Game reset======", client)
-    } else if cmdParts[0] == ":game" {
-        if game.master != client {
-            game.Inform("Only master can switch to game mode!", client)
+        if game.paused {
+            game.refuse(client, "press.paused")
             return
         }
-        game.Reset()
-        game.gameMode = true
-        game.Broadcast("===========Game Mode On===========")
-    } else if cmdParts[0] == ":chat" {
-        if game.master != client {
-            game.Inform("Only master can switch to chat mode!", client)
+        if game.earlyPress(client) || game.contend(client) {
             return
         }
-        game.Reset()
-        game.gameMode = false
-        game.Broadcast("===========Chat Mode On===========")
-    } else if cmdParts[0] == ":exit" {
-        if game.master != client {
-            game.Inform("Only master can shutdown server!", client)
+        game.takePress(client)
+    } else if game.gameMode && client == game.buttonPressed && client.canAnswer {
+        // answering a question in game mode
+        client.canAnswer = false
+        answer := strings.TrimSuffix(data, string(settings.EOL))
+        game.publish(events.Answer, client.name, "", answer)
+        game.Broadcast(fmt.Sprintf("[%s] %s", client.GetName(), data))
+        game.lastAnswered = client
+        game.buttonPressed = nil
+        game.runHooks(func(h Hooks) { h.OnAnswer(game, client, answer) })
+    } else if !game.gameMode {
+        // chat mode
+        if client.isMuted() {
+            game.Notify(client, "muted.you")
             return
         }
-        game.exit <- true
-        game.Broadcast("Server will be shutdown!")
+        game.Broadcast(fmt.Sprintf("[%s] %s", client.GetName(), data))
     } else {
-        game.Inform(fmt.Sprintf(
-            "Unknown command: '%s'", strings.Join(cmdParts, " ")), client)
+        game.Notify(client, "chat.cannot")
     }
 }
 
-func (game *Game) procEventLoop(client *Client) {
-    for {
-        data := <- client.incoming
-        if strings.HasPrefix(data, ":") {
-            game.ProcessCommand(data, client)
-        } else if data == "\n" {
-            /* special case: in game mode ENTER press means button click
-               a click prior :time command is considered as a false start
-            */
-            if !game.gameMode {
-                // do not send empty messages when chatting, that's not polite!
-                continue
-            }
-            if !client.canAnswer || client != game.buttonPressed && game.buttonPressed != nil {
-                game.Inform("You can't press button now", client)
-                continue
-            }
-            if !game.time {
-                game.Broadcast(fmt.Sprintf("%s has a false start!", client.GetName()))
-                client.canAnswer = false
-                continue
-            }
-            game.buttonPressed = client
-            game.Broadcast(fmt.Sprintf(
-                "%s, your answer?", game.buttonPressed.GetName()))
-            } else if game.gameMode && client == game.buttonPressed && client.canAnswer {
-                // answering a question in game mode
-                client.canAnswer = false
-                toSend := fmt.Sprintf("[%s] %s", client.GetName(), data)
-                game.incoming <- toSend
-                game.buttonPressed = nil
-            } else if !game.gameMode {
-                // chat mode
-                toSend := fmt.Sprintf("[%s] %s", client.GetName(), data)
-                game.incoming <- toSend
-            } else {
-                game.Inform("You can't chat right now!", client)
-            }
-        }
-}
-
 func (game *Game) Join(conn net.Conn) *Client {
     clientNum := strconv.Itoa(len(game.Clients) + 1)
     client := NewClient(
-        conn, fmt.Sprintf("anonymous player %s", clientNum))
+        conn, game.server.nextClientId(), fmt.Sprintf("anonymous player %s", clientNum))
     // add client-game reference
-    client.Game = game
+    client.setRoom(game)
+    client.log = game.log.With("client", client.id, "addr", conn.RemoteAddr())
+    client.token = newSessionToken()
+    client.Listen()
     game.Clients = append(game.Clients, client)
     game.SystemMsg(
         fmt.Sprintf("'%s' has joined (%s). Total clients: %d",
                     client.name, client.conn.RemoteAddr(),
                     len(game.GetClientsOnline())),
         true)
-    game.Broadcast(fmt.Sprintf("'%s' has joined us!", client.GetName()))
-    go game.procEventLoop(client)
+    game.publish(events.Join, client.name, "", client.conn.RemoteAddr().String())
+    game.replayTail(client)
+    game.Announce("join.done", client.GetName())
+    game.sendSession(client)
+    game.runHooks(func(h Hooks) { h.OnJoin(game, client) })
+    go client.procEventLoop()
     return client
 }
 
-func (game *Game) notifyListener(msg string) {
-    // notify that client has been created
-    ch := game.server.stateCh
-    if ch != nil {
-        ch <- msg
+// hand an event over to whoever watches the server
+func (game *Game) publish(kind events.Kind, actor string, target string, payload string) {
+    if game.server == nil {
+        return
     }
+    game.server.Events.Publish(events.New(kind, game.Name, actor, target, payload))
 }
 
 func (game *Game) Listen() {
     go func() {
         for {
             select {
-            case data := <-game.incoming:
-                game.Broadcast(data)
             case conn := <-game.joins:
                 game.Join(conn)
-            case <- game.timeout:
+            case gen := <- game.timeout:
+                if gen != game.timerGen {
+                    // stopped or restarted meanwhile
+                    continue
+                }
                 if game.buttonPressed == nil {
-                    game.Broadcast("===========Time is Out===========")
+                    game.publish(events.Timeout, "", "", "")
+                    game.cue(events.CueTimeout, nil)
+                    game.server.metrics.timeouts.With(game.Name).Inc()
+                    game.Announce("timer.out")
+                    game.runHooks(func(h Hooks) { h.OnTimeout(game) })
                     game.Reset()
+                    game.roundOver()
                 }
+            case action := <-game.actions:
+                action()
             case <- game.exit:
                 game.SystemMsg("Closing client connections..", false)
                 for _, cl := range game.GetClientsOnline() {
-                    game.SystemMsg(fmt.Sprintf("Disconnecting client %s", cl.conn.RemoteAddr()), false)
+                    cl.log.Info("disconnecting client")
                     cl.Exit()
                 }
                 // for bug-evading purposes only
                 game.SystemMsg(fmt.Sprintf("Done! Clients left: %d", len(game.GetClientsOnline())), true)
-                game.SystemMsg("Shutting down server..", false)
-                game.server.listener.Stop()
+                game.server.removeGame(game)
+                close(game.done)
                 return
             }
         }
     }()
 }
 
-func NewGame() *Game {
+func NewGame(name string) *Game {
     game := &Game{
-        incoming: make(chan string),
-        timeout: make(chan time.Time),
+        Name: name,
+        log: logger.Default().With("room", name),
+        timeout: make(chan int),
+        question: -1,
         Clients: make([]*Client, 0),
         joins: make(chan net.Conn),
         exit: make(chan bool, 1),
+        done: make(chan bool),
+        actions: make(chan func()),
+    }
+    policy, err := parseFalseStartPolicy(strings.Fields(settings.FalseStart))
+    if err != nil {
+        game.log.Warn("bad false start rule, using lockout", "rule", settings.FalseStart)
+        policy, _ = parseFalseStartPolicy([]string{"lockout"})
     }
+    game.falseStart = policy
     game.Listen()
 
     return game
@@ -388,39 +674,112 @@ func NewGame() *Game {
 
 type Server struct {
     Games []*Game
-    // a channel passed from outside to monitor up/down state
     listener *listener.StoppableListener
-    stateCh chan string
+    // everything happening in the games is published here,
+    // subscribe to monitor the server
+    Events *events.Bus
+    Metrics *metrics.Registry
+    metrics *serverMetrics
+    log *logger.Logger
     wg *sync.WaitGroup
+    // guards Games and lastClientId
+    mu sync.Mutex
+    lastClientId int
+    stopOnce sync.Once
+    bans *banList
+    // guards the tournament fields
+    tmu sync.Mutex
+    // one scheduling pass at a time, see scheduleMatches
+    smu sync.Mutex
+    // teams registered for the next tournament
+    entrants []string
+    tournament *tournament.Tournament
+    // how tournament matches are played
+    matchQuestions int
+    matchTarget int
+    // what rooms are created with, see plugins.go
+    plugins []pluginSpec
 }
 
-func (server *Server) addGame() *Game{
-    // for server start sync
-    game := NewGame()
+// the room with that name, created if there's none
+func (server *Server) openRoom(name string) *Game {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+    for _, game := range server.Games {
+        if game.Name == name {
+            return game
+        }
+    }
+    game := NewGame(name)
     game.server = server
+    game.hooks = server.newHooks(name)
     server.Games = append(server.Games, game)
     return game
 }
 
-func (server *Server) notifyListener(msg string) {
-    // notify that client has been created
-    if server.stateCh != nil {
-        server.stateCh <- msg
+func (server *Server) removeGame(game *Game) {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+    for i, g := range server.Games {
+        if g == game {
+            server.Games = append(server.Games[:i], server.Games[i+1:]...)
+            return
+        }
+    }
+}
+
+// returns a copy, safe to iterate while games come and go
+func (server *Server) Rooms() []*Game {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+    return append([]*Game(nil), server.Games...)
+}
+
+func (server *Server) Room(name string) *Game {
+    for _, game := range server.Rooms() {
+        if game.Name == name {
+            return game
+        }
     }
+    return nil
 }
 
-func NewServer(host string, port int, stateCh chan string) (*Server) {
+// the room new connections go to, recreated if it has been shut down
+func (server *Server) lobby() *Game {
+    return server.openRoom(settings.DefaultRoom)
+}
+
+func (server *Server) nextClientId() int {
+    server.mu.Lock()
+    defer server.mu.Unlock()
+    server.lastClientId++
+    return server.lastClientId
+}
+
+func NewServer(host string, port int) (*Server) {
     ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
     utils.ProcError(err)
     // use stoppable listener further on
     sl, err := listener.New(ln)
     utils.ProcError(err)
-    s := &Server{make([]*Game, 0), sl, stateCh, &sync.WaitGroup{}}
+    s := &Server{Games: make([]*Game, 0),
+                 listener: sl,
+                 Events: events.NewBus(),
+                 Metrics: metrics.NewRegistry(),
+                 log: logger.Default(),
+                 wg: &sync.WaitGroup{}}
+    s.metrics = newServerMetrics(s)
+    if s.bans, err = loadBans(settings.BanFile); err != nil {
+        s.log.Error("cannot load bans, starting with what could be read", "path", settings.BanFile, "err", err)
+    }
+    if s.plugins, err = parsePluginSpecs(settings.Plugins); err != nil {
+        s.log.Error("cannot load plugins, starting without them", "plugins", settings.Plugins, "err", err)
+    }
     return s
 }
 
 func (s *Server) Start(){
-    game := s.addGame()
+    game := s.lobby()
     game.SystemMsg("Launching Brain Server...", true)
     for {
         conn, err := s.listener.Accept()
@@ -430,13 +789,29 @@ func (s *Server) Start(){
         } else {
             utils.ProcError(err)
         }
-        game.joins <- conn
+        if ban := s.bans.find(hostOf(conn.RemoteAddr()), false); ban != nil {
+            s.log.Info("refused a banned address", "addr", conn.RemoteAddr())
+            fmt.Fprintf(conn, "%s%c", i18n.M("ban.you").In(settings.Language), settings.EOL)
+            conn.Close()
+            continue
+        }
+        game = s.lobby()
+        select {
+        case game.joins <- conn:
+        case <-game.done:
+            // the room has just been shut down
+            conn.Close()
+        }
     }
-    s.Stop()
 }
 
+// shuts every room down, then stops accepting connections
 func (s *Server) Stop() {
-    for _, game := range s.Games {
-        game.exit <- true
+    for _, game := range s.Rooms() {
+        game.Shutdown()
     }
+    s.stopOnce.Do(func() {
+        s.log.Info("shutting down server")
+        s.listener.Stop()
+    })
 }
@@ -0,0 +1,110 @@
+package server
+
+
+import (
+    "crypto/subtle"
+    "events"
+    "protocol"
+    "settings"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// bridges of hardware buttons (see client/bridge.go) press for the teams
+// at a table and tell when a button has been pressed with ":press <ms>".
+// A connection becomes a bridge with ":bridge <token>" and the token of
+// settings.BridgeToken, the dates of everybody else are ignored: a player
+// could press in the past otherwise. While a bridge is in the room presses
+// coming within settings.PressWindow of the first one are put in the order
+// they have been made in, not the order they have arrived in
+
+// ":bridge <token>"
+func (game *Game) procBridgeCmd(cmdParts []string, client *Client) {
+    token := settings.BridgeToken
+    if token == "" || subtle.ConstantTimeCompare([]byte(cmdParts[1]), []byte(token)) != 1 {
+        game.Notify(client, "bridge.denied")
+        return
+    }
+    client.bridge = true
+    game.Notify(client, "bridge.on")
+}
+
+// the age of a ":press <ms>" line, kept within settings.MaxPressAge
+func pressAge(line string) (time.Duration, bool) {
+    words := strings.Fields(line)
+    if len(words) != 2 || words[0] != protocol.Press {
+        return 0, false
+    }
+    ms, err := strconv.Atoi(words[1])
+    if err != nil {
+        return 0, false
+    }
+    age := time.Duration(ms) * time.Millisecond
+    if age < 0 {
+        age = 0
+    }
+    if age > settings.MaxPressAge {
+        age = settings.MaxPressAge
+    }
+    return age, true
+}
+
+func (game *Game) hasBridges() bool {
+    for _, client := range game.GetClientsOnline() {
+        if client.bridge {
+            return true
+        }
+    }
+    return false
+}
+
+// holds the press back until the window is over, false if there's no
+// need to
+func (game *Game) contend(client *Client) bool {
+    if settings.PressWindow <= 0 || !game.hasBridges() {
+        return false
+    }
+    if len(game.contenders) == 0 {
+        game.pressGen++
+        gen := game.pressGen
+        time.AfterFunc(settings.PressWindow, func() {
+            // fails if the room has been shut down by then, nobody to answer
+            game.Do(func() { game.settlePresses(gen) })
+        })
+    }
+    for _, cl := range game.contenders {
+        if cl == client {
+            return true
+        }
+    }
+    game.contenders = append(game.contenders, client)
+    return true
+}
+
+// the one who has pressed first answers, the others are late
+func (game *Game) settlePresses(gen int) {
+    if gen != game.pressGen {
+        // reset meanwhile
+        return
+    }
+    contenders := game.contenders
+    game.contenders = nil
+    if !game.time || game.paused || game.buttonPressed != nil {
+        return
+    }
+    sort.SliceStable(contenders, func(i, j int) bool {
+        return contenders[i].pressTime.Before(contenders[j].pressTime)
+    })
+    for _, client := range contenders {
+        if client.disconnected || !client.canAnswer {
+            continue
+        }
+        if game.buttonPressed == nil {
+            game.takePress(client)
+        } else {
+            game.publish(events.LatePress, client.name, "", "")
+        }
+    }
+}
@@ -0,0 +1,208 @@
+package server
+
+
+import (
+    "fmt"
+    "i18n"
+    "settings"
+    "strings"
+    "unicode"
+)
+
+// besides the room chat players have direct messages (":msg <name> text"),
+// a team channel only teammates see (":t text"), handy for discussing the
+// question while the countdown goes, and masters have one of their own
+// (":masters text"). The master can turn team chat off with ":teamchat off".
+// whoever names a new team founds it, to get into a team that has players
+// online one asks with ":team <name>" and a teammate lets them in with
+// ":team accept <name|#id>"
+
+const teamUsage = ":team [name | leave | accept <name|#id>]"
+
+// commands that are chat lines as far as flood protection is concerned
+var chatCommands = map[string]bool{":msg": true, ":t": true, ":masters": true}
+
+func isChatCommand(line string) bool {
+    return chatCommands[strings.SplitN(strings.TrimSpace(line), " ", 2)[0]]
+}
+
+// sends msg to every recipient once
+func (game *Game) tell(msg *i18n.Message, recipients ...*Client) {
+    seen := make(map[*Client]bool)
+    for _, client := range recipients {
+        if client != nil && !client.disconnected && !seen[client] {
+            seen[client] = true
+            game.say(msg, client)
+        }
+    }
+}
+
+// muted players can't use the channels either
+func (game *Game) maySpeak(client *Client) bool {
+    if client.isMuted() {
+        game.Notify(client, "muted.you")
+        return false
+    }
+    return true
+}
+
+// ":msg <name|#id> <text>", names of several words need the #id
+func (game *Game) procMsgCmd(cmdParts []string, client *Client) {
+    if !game.maySpeak(client) {
+        return
+    }
+    target, err := game.findClient(cmdParts[1])
+    if err != nil {
+        game.Notify(client, "find.failed", cmdParts[1], err)
+        return
+    }
+    if target == client {
+        game.Notify(client, "msg.self")
+        return
+    }
+    text := strings.Join(cmdParts[2:], " ")
+    game.tell(i18n.Text(fmt.Sprintf("[%s -> %s] %s", client.GetName(), target.GetName(), text)), client, target)
+}
+
+func (game *Game) teammates(team string) []*Client {
+    var members []*Client
+    for _, cl := range game.GetClientsOnline() {
+        if cl.team == team {
+            members = append(members, cl)
+        }
+    }
+    return members
+}
+
+// ":team" shows the team, ":team <name>" founds or asks to join one,
+// ":team accept <name|#id>" lets a player in, ":team leave" leaves it
+func (game *Game) procTeamCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        if client.team == "" {
+            game.Notify(client, "team.none")
+            return
+        }
+        var names []string
+        for _, cl := range game.teammates(client.team) {
+            names = append(names, cl.GetName())
+        }
+        game.Notify(client, "team.members", client.team, strings.Join(names, ", "))
+        return
+    }
+    if len(cmdParts) == 2 && cmdParts[1] == "leave" {
+        if client.team == "" {
+            game.Notify(client, "team.not_in")
+            return
+        }
+        team := client.team
+        client.team = ""
+        game.Notify(client, "team.left.you", team)
+        game.tell(i18n.M("team.left", client.GetName(), team), game.teammates(team)...)
+        return
+    }
+    if cmdParts[1] == "accept" {
+        game.acceptTeammate(cmdParts[2:], client)
+        return
+    }
+    team := strings.Join(cmdParts[1:], " ")
+    if len([]rune(team)) > settings.MaxNameLength || strings.IndexFunc(team, unicode.IsControl) >= 0 {
+        game.Notify(client, "team.bad_name", settings.MaxNameLength)
+        return
+    }
+    if team == client.team {
+        game.Notify(client, "team.already", team)
+        return
+    }
+    if members := game.teammates(team); len(members) > 0 {
+        client.wantsTeam = team
+        game.Notify(client, "team.asked", team)
+        game.tell(i18n.M("team.ask", client.GetName(), team, client.id), members...)
+        return
+    }
+    client.wantsTeam = ""
+    client.team = team
+    game.tell(i18n.M("team.joined", client.GetName(), team), client)
+}
+
+// a teammate lets in a player who has asked to join
+func (game *Game) acceptTeammate(args []string, client *Client) {
+    if client.team == "" {
+        game.Notify(client, "team.not_in")
+        return
+    }
+    if len(args) == 0 {
+        game.Notify(client, "usage", teamUsage)
+        return
+    }
+    ref := strings.Join(args, " ")
+    target, err := game.findClient(ref)
+    if err != nil {
+        game.Notify(client, "find.failed", ref, err)
+        return
+    }
+    if target.wantsTeam != client.team {
+        game.Notify(client, "team.not_asked", target.GetName(), client.team)
+        return
+    }
+    target.wantsTeam = ""
+    target.team = client.team
+    game.tell(i18n.M("team.joined", target.GetName(), target.team), game.teammates(target.team)...)
+}
+
+// ":t <text>" to the teammates
+func (game *Game) procTeamSayCmd(cmdParts []string, client *Client) {
+    if !game.maySpeak(client) {
+        return
+    }
+    if client.team == "" {
+        game.Notify(client, "team.none")
+        return
+    }
+    if game.noTeamChat {
+        game.Notify(client, "teamchat.off.you")
+        return
+    }
+    text := strings.Join(cmdParts[1:], " ")
+    game.tell(i18n.M("channel.team", client.GetName(), client.team, text), game.teammates(client.team)...)
+}
+
+// ":masters <text>" between the master and co-masters
+func (game *Game) procMastersSayCmd(cmdParts []string, client *Client) {
+    if game.master != client && !game.isCoMaster(client) {
+        game.Notify(client, "only.masters")
+        return
+    }
+    if !game.maySpeak(client) {
+        return
+    }
+    text := strings.Join(cmdParts[1:], " ")
+    recipients := []*Client{game.master}
+    for _, cl := range game.GetClientsOnline() {
+        if game.isCoMaster(cl) {
+            recipients = append(recipients, cl)
+        }
+    }
+    game.tell(i18n.M("channel.masters", client.GetName(), text), recipients...)
+}
+
+// ":teamchat on|off"
+func (game *Game) procTeamChatCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        if game.noTeamChat {
+            game.Notify(client, "teamchat.off.you")
+        } else {
+            game.Notify(client, "teamchat.on.you")
+        }
+        return
+    }
+    if !game.allowed(client, ":teamchat") {
+        game.Notify(client, "only.teamchat")
+        return
+    }
+    if len(cmdParts) != 2 || cmdParts[1] != "on" && cmdParts[1] != "off" {
+        game.Notify(client, "usage", ":teamchat on|off")
+        return
+    }
+    game.noTeamChat = cmdParts[1] == "off"
+    game.Announce("teamchat." + cmdParts[1])
+}
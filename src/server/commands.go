@@ -0,0 +1,442 @@
+package server
+
+
+import (
+    "events"
+    "fmt"
+    "i18n"
+    "protocol"
+    "settings"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// every ":command" is declared once: its name and aliases, who may run it,
+// in which mode, its arguments and help. ProcessCommand checks all of that
+// before running it, so commands only do their job. Game formats and
+// plugins add their own with RegisterCommand
+
+// who may run a command
+type Role int
+
+const (
+    Anyone Role = iota
+    // the master and co-masters with the command's right
+    Master
+    // the master only
+    MasterOnly
+)
+
+// modes a command can be run in
+type Mode int
+
+const (
+    AnyMode Mode = iota
+    GameMode
+    ChatMode
+)
+
+type ArgKind int
+
+const (
+    // a single word
+    Word ArgKind = iota
+    Int
+    // the rest of the line, the last argument only
+    Text
+)
+
+type Arg struct {
+    Name string
+    Kind ArgKind
+    Optional bool
+}
+
+type Command struct {
+    Name string
+    Aliases []string
+    Role Role
+    // co-master right covering the command, for the Master role
+    Right string
+    Mode Mode
+    // the mode is checked before the role, players typing :time in chat
+    // mode have always been told to enter game mode first
+    ModeFirst bool
+    Args []Arg
+    // overrides the usage made of Args, for commands with subcommands
+    Usage string
+    // message ids of the description and of what those not allowed to run
+    // the command are told, the latter defaults to "only.master"
+    Help string
+    Denied string
+    // kept out of :help, e.g. the ones clients send on their own
+    Hidden bool
+    // the master can take it back with :undo
+    Undoable bool
+    Run func(game *Game, client *Client, call *Call)
+}
+
+// a command as typed
+type Call struct {
+    Command *Command
+    // the words typed, the first one is the command's name even if an
+    // alias has been typed
+    Parts []string
+    // by Command.Args, "" for the optional ones not given
+    Args []string
+}
+
+// the i-th argument or def if it is not given, Int arguments are checked
+// before the command runs
+func (call *Call) Int(i int, def int) int {
+    if i >= len(call.Args) || call.Args[i] == "" {
+        return def
+    }
+    n, _ := strconv.Atoi(call.Args[i])
+    return n
+}
+
+var commands = make(map[string]*Command)
+
+func RegisterCommand(cmd *Command) error {
+    names := append([]string{cmd.Name}, cmd.Aliases...)
+    for _, name := range names {
+        if !strings.HasPrefix(name, ":") {
+            return fmt.Errorf("command %s: '%s' should start with ':'", cmd.Name, name)
+        }
+        if _, ok := commands[name]; ok {
+            return fmt.Errorf("command %s: '%s' is taken", cmd.Name, name)
+        }
+    }
+    for i, arg := range cmd.Args {
+        if arg.Kind == Text && i != len(cmd.Args) - 1 {
+            return fmt.Errorf("command %s: text argument %s is not the last one", cmd.Name, arg.Name)
+        }
+    }
+    if cmd.Right != "" {
+        if _, ok := masterRights[cmd.Right]; !ok {
+            return fmt.Errorf("command %s: no such right '%s'", cmd.Name, cmd.Right)
+        }
+        masterRights[cmd.Right] = append(masterRights[cmd.Right], cmd.Name)
+        rightOf[cmd.Name] = cmd.Right
+    }
+    for _, name := range names {
+        commands[name] = cmd
+    }
+    return nil
+}
+
+func mustRegister(cmds ...*Command) {
+    for _, cmd := range cmds {
+        if err := RegisterCommand(cmd); err != nil {
+            panic(err)
+        }
+    }
+}
+
+func (cmd *Command) usage() string {
+    if cmd.Usage != "" {
+        return cmd.Usage
+    }
+    parts := []string{cmd.Name}
+    for _, arg := range cmd.Args {
+        name := arg.Name
+        if arg.Kind == Text {
+            name += "..."
+        }
+        if arg.Optional {
+            parts = append(parts, "[" + name + "]")
+        } else {
+            parts = append(parts, "<" + name + ">")
+        }
+    }
+    return strings.Join(parts, " ")
+}
+
+func (game *Game) mayRun(client *Client, cmd *Command) bool {
+    switch cmd.Role {
+    case Master:
+        return game.allowed(client, cmd.Name)
+    case MasterOnly:
+        return game.master == client
+    }
+    return true
+}
+
+// splits the arguments by the schema, the error says what's wrong
+func (cmd *Command) parse(words []string) ([]string, error) {
+    args := make([]string, len(cmd.Args))
+    for i, arg := range cmd.Args {
+        if len(words) == 0 {
+            if !arg.Optional {
+                return nil, i18n.M("args.missing", arg.Name)
+            }
+            continue
+        }
+        if arg.Kind == Text {
+            args[i] = strings.Join(words, " ")
+            words = nil
+            continue
+        }
+        if arg.Kind == Int {
+            if _, err := strconv.Atoi(words[0]); err != nil {
+                return nil, i18n.M("args.integer", arg.Name, words[0])
+            }
+        }
+        args[i] = words[0]
+        words = words[1:]
+    }
+    if len(words) > 0 {
+        return nil, i18n.M("args.extra", strings.Join(words, " "))
+    }
+    return args, nil
+}
+
+func (game *Game) ProcessCommand(line string, client *Client) {
+    cmdParts := sanitizeCommandString(line)
+    cmd, ok := commands[cmdParts[0]]
+    if !ok {
+        game.refuse(client, "command.unknown", strings.Join(cmdParts, " "))
+        return
+    }
+    if refusal := game.runCommand(cmd, cmdParts, client); refusal != nil {
+        game.say(refusal, client)
+    }
+}
+
+// runs the command for the client, unless the client may not run it now:
+// the message tells why then
+func (game *Game) runCommand(cmd *Command, cmdParts []string, client *Client) *i18n.Message {
+    if cmd.ModeFirst {
+        if refusal := game.wrongMode(cmd); refusal != nil {
+            return refusal
+        }
+    }
+    if !game.mayRun(client, cmd) {
+        if cmd.Denied != "" {
+            return i18n.M(cmd.Denied)
+        }
+        return i18n.M("only.master", cmd.Name)
+    }
+    if !cmd.ModeFirst {
+        if refusal := game.wrongMode(cmd); refusal != nil {
+            return refusal
+        }
+    }
+    args, err := cmd.parse(cmdParts[1:])
+    if err != nil {
+        return i18n.M("args.bad", cmd.Name, err, cmd.usage())
+    }
+    cmdParts[0] = cmd.Name
+    if cmd.Undoable {
+        defer game.recordUndo(strings.Join(cmdParts, " "), game.snapshot(), game.undoGen)
+    }
+    cmd.Run(game, client, &Call{Command: cmd, Parts: cmdParts, Args: args})
+    return nil
+}
+
+// nil unless the room is in the wrong mode for the command
+func (game *Game) wrongMode(cmd *Command) *i18n.Message {
+    if cmd.Mode == GameMode && !game.gameMode {
+        return i18n.M("game.first")
+    }
+    if cmd.Mode == ChatMode && game.gameMode {
+        return i18n.M("chat.first")
+    }
+    return nil
+}
+
+// ":help [command]"
+func (game *Game) procHelpCmd(call *Call, client *Client) {
+    if call.Args[0] == "" {
+        var names []string
+        for name, cmd := range commands {
+            if name == cmd.Name && !cmd.Hidden && game.mayRun(client, cmd) {
+                names = append(names, name)
+            }
+        }
+        sort.Strings(names)
+        game.Notify(client, "help.commands", strings.Join(names, ", "))
+        game.Notify(client, "help.more")
+        return
+    }
+    name := call.Args[0]
+    if !strings.HasPrefix(name, ":") {
+        name = ":" + name
+    }
+    cmd, ok := commands[name]
+    if !ok || cmd.Hidden {
+        game.Notify(client, "help.unknown", call.Args[0])
+        return
+    }
+    game.Notify(client, "usage", cmd.usage())
+    if cmd.Help != "" {
+        game.Notify(client, cmd.Help)
+    }
+    if len(cmd.Aliases) > 0 {
+        game.Notify(client, "help.aliases", strings.Join(cmd.Aliases, ", "))
+    }
+    switch cmd.Role {
+    case Master:
+        if right := rightOf[cmd.Name]; right != "" {
+            game.Notify(client, "help.role.master.right", right)
+        } else {
+            game.Notify(client, "help.role.master")
+        }
+    case MasterOnly:
+        game.Notify(client, "help.role.master")
+    }
+    if cmd.Mode == GameMode {
+        game.Notify(client, "help.mode.game")
+    } else if cmd.Mode == ChatMode {
+        game.Notify(client, "help.mode.chat")
+    }
+}
+
+// commands with the word lists they used to get
+func parts(proc func(game *Game, cmdParts []string, client *Client)) func(*Game, *Client, *Call) {
+    return func(game *Game, client *Client, call *Call) {
+        proc(game, call.Parts, client)
+    }
+}
+
+func plain(proc func(game *Game, client *Client)) func(*Game, *Client, *Call) {
+    return func(game *Game, client *Client, call *Call) {
+        proc(game, client)
+    }
+}
+
+var player = []Arg{{Name: "name|#id", Kind: Text}}
+
+func init() {
+    mustRegister(
+        &Command{Name: ":help", Aliases: []string{":?"}, Args: []Arg{{Name: "command", Optional: true}},
+                 Help: "help.help", Run: func(game *Game, client *Client, call *Call) {
+                     game.procHelpCmd(call, client)
+                 }},
+        // an empty name is for rename to turn down
+        &Command{Name: ":rename", Args: []Arg{{Name: "name", Kind: Text, Optional: true}}, Help: "help.rename",
+                 Run: func(game *Game, client *Client, call *Call) {
+                     if err := game.rename(client, call.Args[0]); err != nil {
+                         game.Notify(client, "rename.failed", call.Args[0], err)
+                     }
+                 }},
+        &Command{Name: ":who", Help: "help.who", Run: plain((*Game).procWhoCmd)},
+        &Command{Name: ":lang", Args: []Arg{{Name: "language", Optional: true}}, Help: "help.lang",
+                 Run: parts((*Game).procLangCmd)},
+        &Command{Name: ":history", Args: []Arg{{Name: "messages", Kind: Int, Optional: true}},
+                 Help: "help.history", Run: parts((*Game).procHistoryCmd)},
+        &Command{Name: ":join", Args: []Arg{{Name: "room"}}, Help: "help.join", Run: parts((*Game).procJoinCmd)},
+        // masters
+        &Command{Name: ":master", Args: []Arg{{Name: "give|resign", Kind: Text, Optional: true}},
+                 Usage: masterUsage, Help: "help.master", Run: parts((*Game).procMasterCmd)},
+        &Command{Name: ":comaster", Args: []Arg{{Name: "name|#id", Kind: Text, Optional: true}},
+                 Usage: coMasterUsage, Help: "help.comaster", Run: parts((*Game).procCoMasterCmd)},
+        &Command{Name: ":exit", Role: MasterOnly, Denied: "only.exit", Help: "help.exit",
+                 Run: func(game *Game, client *Client, call *Call) {
+                     // the other rooms play on
+                     game.Announce("room.shutdown", game.Name)
+                     go game.Shutdown()
+                 }},
+        // the game
+        &Command{Name: ":game", Role: Master, Denied: "only.game", Help: "help.game", Undoable: true,
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.Reset()
+                     game.gameMode = true
+                     game.publish(events.Mode, client.name, "", "game")
+                     game.Announce("mode.game")
+                 }},
+        &Command{Name: ":chat", Role: Master, Denied: "only.chat", Help: "help.chat", Undoable: true,
+                 Run: func(game *Game, client *Client, call *Call) {
+                     if game.match != nil {
+                         game.abortMatch()
+                         return
+                     }
+                     game.Reset()
+                     game.gameMode = false
+                     game.publish(events.Mode, client.name, "", "chat")
+                     game.Announce("mode.chat")
+                 }},
+        &Command{Name: ":reset", Role: Master, Mode: GameMode, Denied: "only.reset", Help: "help.reset",
+                 Undoable: true, Run: func(game *Game, client *Client, call *Call) {
+                     game.Reset()
+                     game.Notify(client, "reset.done")
+                 }},
+        &Command{Name: ":time", Role: Master, Mode: GameMode, ModeFirst: true, Args: []Arg{{Name: "seconds", Kind: Int, Optional: true}},
+                 Denied: "only.countdown", Help: "help.time", Undoable: true,
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.procTimeCmd(call.Int(0, settings.RoundTimeout), client)
+                 }},
+        &Command{Name: ":pause", Role: Master, Denied: "only.pause", Help: "help.pause", Undoable: true,
+                 Run: plain((*Game).procPauseCmd)},
+        &Command{Name: ":resume", Role: Master, Denied: "only.resume", Help: "help.resume", Undoable: true,
+                 Run: plain((*Game).procResumeCmd)},
+        &Command{Name: ":accept", Role: Master, Mode: GameMode, Args: []Arg{{Name: "points", Kind: Int, Optional: true}},
+                 Denied: "only.judge", Help: "help.accept", Undoable: true,
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.judge(client, true, call.Int(0, 1))
+                 }},
+        &Command{Name: ":reject", Role: Master, Mode: GameMode, Args: []Arg{{Name: "points", Kind: Int, Optional: true}},
+                 Denied: "only.judge", Help: "help.reject", Undoable: true,
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.judge(client, false, call.Int(0, 1))
+                 }},
+        &Command{Name: ":falsestart", Args: []Arg{{Name: "rule", Kind: Text, Optional: true}},
+                 Usage: falseStartUsage, Help: "help.falsestart", Undoable: true,
+                 Run: parts((*Game).procFalseStartCmd)},
+        &Command{Name: ":undo", Role: MasterOnly, Denied: "only.undo", Help: "help.undo",
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.procUndoCmd(client, false)
+                 }},
+        &Command{Name: ":redo", Role: MasterOnly, Denied: "only.undo", Help: "help.redo",
+                 Run: func(game *Game, client *Client, call *Call) {
+                     game.procUndoCmd(client, true)
+                 }},
+        // questions
+        &Command{Name: ":pack", Role: Master, Args: []Arg{{Name: "file"}}, Denied: "only.pack",
+                 Help: "help.pack", Undoable: true, Run: parts((*Game).procPackCmd)},
+        &Command{Name: ":next", Role: Master, Mode: GameMode, Denied: "only.next", Help: "help.next",
+                 Undoable: true, Run: plain((*Game).procNextCmd)},
+        &Command{Name: ":reveal", Role: Master, Mode: GameMode, Denied: "only.reveal", Help: "help.reveal",
+                 Run: plain((*Game).procRevealCmd)},
+        // matches and tournaments
+        &Command{Name: ":match", Args: []Arg{{Name: "teams", Kind: Text, Optional: true}},
+                 Usage: matchUsage, Help: "help.match", Undoable: true, Run: parts((*Game).procMatchCmd)},
+        &Command{Name: ":tournament", Args: []Arg{{Name: "subcommand", Kind: Text, Optional: true}},
+                 Usage: tournamentUsage, Help: "help.tournament", Run: parts((*Game).procTournamentCmd)},
+        &Command{Name: ":bracket", Help: "help.bracket", Run: plain((*Game).procBracketCmd)},
+        // moderation
+        &Command{Name: ":mute", Role: Master, Args: player,
+                 Usage: ":mute <name|#id> [seconds]", Denied: "only.mute", Help: "help.mute",
+                 Run: parts((*Game).procMuteCmd)},
+        &Command{Name: ":unmute", Role: Master, Args: player, Denied: "only.mute", Help: "help.unmute",
+                 Run: parts((*Game).procMuteCmd)},
+        &Command{Name: ":kick", Role: Master, Args: player, Denied: "only.kick", Help: "help.kick",
+                 Run: parts((*Game).procKickCmd)},
+        &Command{Name: ":ban", Role: MasterOnly, Args: player,
+                 Usage: ":ban <name|#id|ip> [duration]", Denied: "only.ban", Help: "help.ban",
+                 Run: parts((*Game).procBanCmd)},
+        &Command{Name: ":unban", Role: MasterOnly, Args: []Arg{{Name: "name|ip", Kind: Text}},
+                 Denied: "only.unban", Help: "help.unban", Run: parts((*Game).procUnbanCmd)},
+        &Command{Name: ":bans", Role: MasterOnly, Denied: "only.bans", Help: "help.bans",
+                 Run: plain((*Game).procBansCmd)},
+        // channels
+        &Command{Name: ":msg", Aliases: []string{":tell"}, Args: []Arg{{Name: "name|#id"}, {Name: "text", Kind: Text}},
+                 Help: "help.msg", Run: parts((*Game).procMsgCmd)},
+        &Command{Name: ":team", Args: []Arg{{Name: "name|leave|accept", Kind: Text, Optional: true}},
+                 Help: "help.team", Run: parts((*Game).procTeamCmd)},
+        &Command{Name: ":t", Args: []Arg{{Name: "text", Kind: Text}}, Help: "help.t", Run: parts((*Game).procTeamSayCmd)},
+        &Command{Name: ":masters", Args: []Arg{{Name: "text", Kind: Text}}, Help: "help.masters",
+                 Run: parts((*Game).procMastersSayCmd)},
+        &Command{Name: ":teamchat", Args: []Arg{{Name: "on|off", Optional: true}}, Help: "help.teamchat",
+                 Run: parts((*Game).procTeamChatCmd)},
+        // sent by clients on their own
+        &Command{Name: protocol.ResumeSession, Args: []Arg{{Name: "token", Kind: Text, Optional: true}},
+                 Hidden: true, Run: parts((*Game).procSessionCmd)},
+        &Command{Name: protocol.Bridge, Args: []Arg{{Name: "token"}},
+                 Hidden: true, Run: parts((*Game).procBridgeCmd)},
+        &Command{Name: protocol.Subscribe, Args: []Arg{{Name: "kinds", Kind: Text, Optional: true}},
+                 Hidden: true, Run: parts((*Game).procEventsCmd)},
+    )
+}
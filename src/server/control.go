@@ -0,0 +1,227 @@
+package server
+
+
+import (
+    "i18n"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// control of a running game from outside of it, e.g. by the admin API.
+// Room state is only changed inside the game loop: the clients' lines are
+// dealt with there, and so is every action passed to Do. The actions use
+// the same helpers ProcessCommand does, so players see exactly what
+// they'd see if the master typed the command
+
+// messages, so that players read them in their language
+var ErrRoomClosed error = i18n.M("err.room_closed")
+var ErrNoSuchClient error = i18n.M("err.no_such_client")
+var ErrHasMaster error = i18n.M("err.has_master")
+var ErrAmbiguousName error = i18n.M("err.ambiguous_name")
+var ErrNoMaster error = i18n.M("err.no_master")
+
+type ClientInfo struct {
+    Id int `json:"id"`
+    Name string `json:"name"`
+    Address string `json:"address"`
+    Role string `json:"role"`
+    Score int `json:"score"`
+    // milliseconds, 0 if not measured yet
+    RTT float64 `json:"rtt_ms"`
+    LastActivity time.Time `json:"last_activity"`
+}
+
+type QuestionInfo struct {
+    Number int `json:"number"`
+    Text string `json:"text"`
+    Answer string `json:"answer,omitempty"`
+    Comment string `json:"comment,omitempty"`
+}
+
+type RoomInfo struct {
+    Name string `json:"name"`
+    // "game" or "chat"
+    Mode string `json:"mode"`
+    TimerRunning bool `json:"timer_running"`
+    TimerPaused bool `json:"timer_paused"`
+    SecondsLeft int `json:"seconds_left"`
+    ButtonPressed string `json:"button_pressed,omitempty"`
+    Master string `json:"master,omitempty"`
+    Match *MatchInfo `json:"match,omitempty"`
+    // the question being played, the answer only once revealed
+    Question *QuestionInfo `json:"question,omitempty"`
+    Clients []ClientInfo `json:"clients"`
+}
+
+func (client *Client) Info() ClientInfo {
+    role := "player"
+    if client.isMaster {
+        role = "master"
+    } else if client.Game != nil && client.Game.isCoMaster(client) {
+        role = "co-master"
+    }
+    return ClientInfo{
+        Id: client.id,
+        Name: client.name,
+        Address: client.conn.RemoteAddr().String(),
+        Role: role,
+        Score: client.score,
+        RTT: float64(client.rtt) / float64(time.Millisecond),
+        LastActivity: client.lastActivity,
+    }
+}
+
+// runs action inside the game loop and waits for it to finish
+func (game *Game) Do(action func()) error {
+    finished := make(chan bool)
+    select {
+    case game.actions <- func() { action(); close(finished) }:
+    case <-game.done:
+        return ErrRoomClosed
+    }
+    <-finished
+    return nil
+}
+
+func (game *Game) Info() (RoomInfo, error) {
+    var info RoomInfo
+    err := game.Do(func() {
+        info = RoomInfo{Name: game.Name, Mode: "chat", TimerRunning: game.time,
+                        Clients: make([]ClientInfo, 0)}
+        if game.gameMode {
+            info.Mode = "game"
+        }
+        info.SecondsLeft = seconds(game.timeLeft())
+        info.TimerPaused = game.paused
+        if game.buttonPressed != nil {
+            info.ButtonPressed = game.buttonPressed.name
+        }
+        if game.master != nil {
+            info.Master = game.master.name
+        }
+        info.Match = game.matchInfo()
+        if q := game.currentQuestion(); q != nil {
+            info.Question = &QuestionInfo{Number: q.Number, Text: q.Text}
+            if game.revealed {
+                info.Question.Answer = q.Answer
+                info.Question.Comment = q.Comment
+            }
+        }
+        for _, client := range game.GetClientsOnline() {
+            info.Clients = append(info.Clients, client.Info())
+        }
+    })
+    return info, err
+}
+
+func (game *Game) clientById(id int) *Client {
+    for _, client := range game.GetClientsOnline() {
+        if client.id == id {
+            return client
+        }
+    }
+    return nil
+}
+
+// the only online client with that name
+func (game *Game) clientByName(name string) (*Client, error) {
+    var found *Client
+    for _, client := range game.GetClientsOnline() {
+        if client.name != name {
+            continue
+        }
+        if found != nil {
+            return nil, ErrAmbiguousName
+        }
+        found = client
+    }
+    if found == nil {
+        return nil, ErrNoSuchClient
+    }
+    return found, nil
+}
+
+// the client referred to as "#<id>" or by its name
+func (game *Game) findClient(ref string) (*Client, error) {
+    if strings.HasPrefix(ref, "#") {
+        if id, err := strconv.Atoi(ref[1:]); err == nil {
+            if client := game.clientById(id); client != nil {
+                return client, nil
+            }
+            return nil, ErrNoSuchClient
+        }
+    }
+    return game.clientByName(ref)
+}
+
+// runs action on the online client with the given id inside the game loop
+func (game *Game) withClient(id int, action func(client *Client) error) error {
+    var result error
+    err := game.Do(func() {
+        client := game.clientById(id)
+        if client == nil {
+            result = ErrNoSuchClient
+            return
+        }
+        result = action(client)
+    })
+    if err != nil {
+        return err
+    }
+    return result
+}
+
+func (game *Game) Kick(id int, reason string) error {
+    return game.withClient(id, func(client *Client) error {
+        if reason == "" {
+            game.kick(client, nil)
+        } else {
+            game.kick(client, i18n.Text(reason))
+        }
+        return nil
+    })
+}
+
+func (game *Game) Rename(id int, name string) error {
+    return game.withClient(id, func(client *Client) error {
+        return game.rename(client, name)
+    })
+}
+
+func (game *Game) GrantMaster(id int) error {
+    return game.withClient(id, func(client *Client) error {
+        return game.takeMaster(client)
+    })
+}
+
+func (game *Game) RevokeMaster() error {
+    return game.Do(game.dropMaster)
+}
+
+// runs ":reset" for the master, with its checks and its undo entry
+func (game *Game) ResetRound() error {
+    var result error
+    err := game.Do(func() {
+        if game.master == nil {
+            result = ErrNoMaster
+            return
+        }
+        if refusal := game.runCommand(commands[":reset"], []string{":reset"}, game.master); refusal != nil {
+            result = refusal
+        }
+    })
+    if err != nil {
+        return err
+    }
+    return result
+}
+
+// disconnects everyone and stops the game loop, returns once it's done
+func (game *Game) Shutdown() {
+    select {
+    case game.exit <- true:
+    case <-game.done:
+    }
+    <-game.done
+}
@@ -0,0 +1,160 @@
+package server
+
+
+import (
+    "events"
+    "i18n"
+    "strconv"
+    "time"
+)
+
+// what happens to a team pressing the button before the countdown starts.
+// The master picks one of the rules with ":falsestart <rule>":
+//
+//  lockout       the team cannot answer the question any more (the default)
+//  award         the team is locked out and the opponent gets to answer
+//  penalty [N]   the team loses N points (1 by default) and may press again
+//  grace [ms]    early presses and those within ms (500 by default) after
+//                the start are ignored
+
+const falseStartUsage = ":falsestart lockout | award | penalty [points] | grace [ms]"
+
+var errBadFalseStart = i18n.M("usage", falseStartUsage)
+
+type falseStartPolicy struct {
+    rule string
+    // penalty only
+    points int
+    // grace only
+    window time.Duration
+}
+
+func parseFalseStartPolicy(args []string) (falseStartPolicy, error) {
+    if len(args) == 0 || len(args) > 2 {
+        return falseStartPolicy{}, errBadFalseStart
+    }
+    policy := falseStartPolicy{rule: args[0], points: 1, window: 500 * time.Millisecond}
+    value := -1
+    if len(args) == 2 {
+        var err error
+        if value, err = strconv.Atoi(args[1]); err != nil || value < 0 {
+            return falseStartPolicy{}, errBadFalseStart
+        }
+    }
+    switch policy.rule {
+    case "lockout", "award":
+        if value >= 0 {
+            return falseStartPolicy{}, errBadFalseStart
+        }
+    case "penalty":
+        if value >= 0 {
+            policy.points = value
+        }
+    case "grace":
+        if value >= 0 {
+            policy.window = time.Duration(value) * time.Millisecond
+        }
+    default:
+        return falseStartPolicy{}, errBadFalseStart
+    }
+    return policy, nil
+}
+
+func (policy falseStartPolicy) message() *i18n.Message {
+    switch policy.rule {
+    case "award":
+        return i18n.M("falsestart.award")
+    case "penalty":
+        return i18n.M("falsestart.penalty", points(policy.points))
+    case "grace":
+        return i18n.M("falsestart.grace", int(policy.window / time.Millisecond))
+    }
+    return i18n.M("falsestart.lockout")
+}
+
+func (policy falseStartPolicy) String() string {
+    return policy.message().String()
+}
+
+func points(n int) *i18n.Message {
+    return i18n.M("points", n)
+}
+
+func (game *Game) procFalseStartCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        game.Notify(client, "falsestart.rule", game.falseStart.message())
+        return
+    }
+    if game.master != client {
+        game.Notify(client, "only.falsestart")
+        return
+    }
+    policy, err := parseFalseStartPolicy(cmdParts[1:])
+    if err != nil {
+        game.Notify(client, "usage", falseStartUsage)
+        return
+    }
+    game.falseStart = policy
+    game.Announce("falsestart.rule", policy.message())
+}
+
+// the one to get the answer when client makes a false start under the
+// award rule: the other team of the match or the only other player who
+// can answer, nil if there's no such one
+func (game *Game) opponent(client *Client) *Client {
+    if game.match != nil && game.match.plays(client) {
+        other := game.clientById(game.match.teams[1 - game.match.team(client)])
+        if other != nil && other.canAnswer {
+            return other
+        }
+        return nil
+    }
+    var found *Client
+    for _, cl := range game.GetClientsOnline() {
+        if cl == client || cl == game.master || !cl.canAnswer {
+            continue
+        }
+        if found != nil {
+            return nil
+        }
+        found = cl
+    }
+    return found
+}
+
+// deals with a press before the countdown or within the grace window,
+// false if the press is in time
+func (game *Game) earlyPress(client *Client) bool {
+    policy := game.falseStart
+    if game.time {
+        if policy.rule == "grace" && client.pressTime.Sub(game.timerStarted) < policy.window {
+            game.refuse(client, "falsestart.early")
+            return true
+        }
+        return false
+    }
+    if policy.rule == "grace" {
+        game.refuse(client, "falsestart.early")
+        return true
+    }
+    game.publish(events.FalseStart, client.name, "", policy.rule)
+    game.cue(events.CueFalseStart, client)
+    game.server.metrics.falseStarts.With(game.Name).Inc()
+    game.Announce("falsestart.done", client.GetName())
+    switch policy.rule {
+    case "penalty":
+        client.score -= policy.points
+        game.Announce("falsestart.loses", client.GetName(), points(policy.points), client.score)
+        game.matchJudged(client, -policy.points)
+    case "award":
+        client.canAnswer = false
+        if opponent := game.opponent(client); opponent != nil {
+            game.buttonPressed = opponent
+            game.publish(events.Press, opponent.name, "", "awarded")
+            game.Announce("press.answer", opponent.GetName())
+        }
+    default:
+        client.canAnswer = false
+    }
+    return true
+}
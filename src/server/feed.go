@@ -0,0 +1,117 @@
+package server
+
+
+import (
+    "events"
+    "fmt"
+    "protocol"
+    "settings"
+    "strconv"
+    "strings"
+)
+
+// game events sent to the clients asking for them with ":events on",
+// that's what rich clients draw their screens from
+
+func (game *Game) roster() []protocol.Player {
+    var players []protocol.Player
+    for _, client := range game.GetClientsOnline() {
+        info := client.Info()
+        players = append(players, protocol.Player{Name: info.Name, Role: info.Role, Score: info.Score})
+    }
+    return players
+}
+
+func (game *Game) procWhoCmd(client *Client) {
+    if client.feed != nil {
+        client.send(protocol.EncodeRoster(game.roster()))
+        return
+    }
+    var names []string
+    for _, cl := range game.GetClientsOnline() {
+        // ids tell players with the same name apart
+        info := cl.Info()
+        name := info.Name
+        if info.Role == "master" {
+            name = "(master) " + name
+        }
+        names = append(names, fmt.Sprintf("#%d %s [%d]", info.Id, name, info.Score))
+    }
+    game.Notify(client, "who.online", strings.Join(names, ", "))
+}
+
+func (game *Game) procEventsCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) != 2 || cmdParts[1] != "on" && cmdParts[1] != "off" {
+        game.Notify(client, "usage", protocol.Subscribe + " on|off")
+        return
+    }
+    if cmdParts[1] == "off" {
+        client.unsubscribe()
+        return
+    }
+    if client.feed != nil {
+        return
+    }
+    client.feed = game.server.Events.Subscribe(settings.SendQueueSize)
+    go client.forwardEvents(client.feed)
+    game.catchUp(client)
+}
+
+// lets a subscribed client catch up with what has happened in the room
+// before it subscribed or came in
+func (game *Game) catchUp(client *Client) {
+    mode := "chat"
+    if game.gameMode {
+        mode = "game"
+    }
+    client.send(protocol.EncodeEvent(events.New(events.Mode, game.Name, "", "", mode)))
+    if left := game.timeLeft(); left > 0 {
+        kind := events.TimerStart
+        if game.paused {
+            kind = events.Pause
+        }
+        client.send(protocol.EncodeEvent(events.New(
+            kind, game.Name, "", "", strconv.Itoa(seconds(left)))))
+    }
+    client.send(protocol.EncodeRoster(game.roster()))
+    if e, ok := game.questionInfo(); ok && client == game.master {
+        client.send(protocol.EncodeEvent(e))
+    }
+}
+
+// signals clients play or show, apart from the text so that they don't
+// have to guess them from it
+func (game *Game) cue(name string, client *Client) {
+    target := ""
+    if client != nil {
+        target = client.name
+    }
+    game.publish(events.Cue, "", target, name)
+}
+
+func (client *Client) forwardEvents(feed *events.Subscription) {
+    for e := range feed.C {
+        // the room and the role as the loop has last set them, a player
+        // just moved or demoted gets no more private events of the room
+        view := client.loadView()
+        if view.game == nil || e.Room != view.game.Name || e.Kind.IsMessage() {
+            // text messages reach the client anyway
+            continue
+        }
+        if e.Private && !view.master {
+            continue
+        }
+        if e.Kind == events.Join || e.Kind == events.Leave {
+            // do not tell players each other's addresses
+            e.Payload = ""
+        }
+        client.send(protocol.EncodeEvent(e))
+    }
+}
+
+func (client *Client) unsubscribe() {
+    if client.feed != nil {
+        client.feed.Close()
+        client.feed = nil
+    }
+}
@@ -0,0 +1,134 @@
+package server
+
+
+import (
+    "bufio"
+    "events"
+    "i18n"
+    "ratelimit"
+    "settings"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// flood protection: chat lines, presses and commands of every client go
+// through token buckets, lines over the limit are dropped. Clients that
+// keep hitting the limits are muted for a while; masters can mute and
+// unmute players by hand
+
+type limits struct {
+    chat *ratelimit.Bucket
+    press *ratelimit.Bucket
+    command *ratelimit.Bucket
+    // every dropped line takes a token, running out of them means a mute
+    abuse *ratelimit.Bucket
+}
+
+func newLimits() limits {
+    return limits{
+        chat: ratelimit.New(settings.ChatRate, settings.ChatBurst),
+        press: ratelimit.New(settings.PressRate, settings.PressBurst),
+        command: ratelimit.New(settings.CommandRate, settings.CommandBurst),
+        abuse: ratelimit.New(float64(settings.FloodMuteAfter) / 60, settings.FloodMuteAfter),
+    }
+}
+
+// reads a line of at most settings.MaxLineLength bytes, the rest of a
+// longer one is skipped and tooLong is set
+func (client *Client) readLine() (line string, tooLong bool, err error) {
+    var buf, chunk []byte
+    for {
+        chunk, err = client.reader.ReadSlice(settings.EOL)
+        if len(buf) + len(chunk) > settings.MaxLineLength {
+            tooLong = true
+        } else {
+            buf = append(buf, chunk...)
+        }
+        if err != bufio.ErrBufferFull {
+            return string(buf), tooLong, err
+        }
+    }
+}
+
+// false if the line should be dropped
+func (client *Client) allow(line string) bool {
+    bucket := client.limits.chat
+    if strings.HasPrefix(line, ":") && !isChatCommand(line) {
+        bucket = client.limits.command
+    } else if line == string(settings.EOL) {
+        bucket = client.limits.press
+    }
+    if bucket.Allow() {
+        client.throttled = false
+        return true
+    }
+    client.violation(i18n.M("flood.slow"))
+    return false
+}
+
+// the client has broken the rules, warns it once and mutes it if it
+// keeps doing so
+func (client *Client) violation(warning *i18n.Message) {
+    game := client.Game
+    if !client.throttled {
+        client.throttled = true
+        game.say(warning, client)
+    }
+    if !client.limits.abuse.Allow() && !client.isMuted() {
+        game.mute(client, settings.FloodMuteFor, i18n.M("flood.flooding"))
+    }
+}
+
+func (client *Client) isMuted() bool {
+    if client.muted && !client.mutedUntil.IsZero() && time.Now().After(client.mutedUntil) {
+        client.muted = false
+    }
+    return client.muted
+}
+
+// d of 0 mutes until unmuted
+func (game *Game) mute(client *Client, d time.Duration, reason *i18n.Message) {
+    client.muted = true
+    client.mutedUntil = time.Time{}
+    game.publish(events.Mute, "", client.name, reason.String())
+    if d > 0 {
+        client.mutedUntil = time.Now().Add(d)
+        game.Announce("mute.done.for", client.GetName(), seconds(d), reason)
+    } else {
+        game.Announce("mute.done", client.GetName(), reason)
+    }
+}
+
+func (game *Game) unmute(client *Client) {
+    client.muted = false
+    game.publish(events.Unmute, "", client.name, "")
+    game.Announce("mute.lifted", client.GetName())
+}
+
+// ":mute <name> [seconds]" and ":unmute <name>"
+func (game *Game) procMuteCmd(cmdParts []string, client *Client) {
+    args := cmdParts[1:]
+    var d time.Duration
+    if cmdParts[0] == ":mute" && len(args) > 1 {
+        if secs, err := strconv.Atoi(args[len(args) - 1]); err == nil && secs > 0 {
+            d = time.Duration(secs) * time.Second
+            args = args[:len(args) - 1]
+        }
+    }
+    name := strings.Join(args, " ")
+    target, err := game.findClient(name)
+    if err != nil {
+        game.Notify(client, "find.failed", name, err)
+        return
+    }
+    if cmdParts[0] == ":unmute" {
+        if !target.isMuted() {
+            game.Notify(client, "mute.not_muted", target.GetName())
+            return
+        }
+        game.unmute(target)
+        return
+    }
+    game.mute(target, d, i18n.M("reason.by", client.GetName()))
+}
@@ -0,0 +1,93 @@
+package server
+
+
+import (
+    "fmt"
+    "i18n"
+    "settings"
+    "strconv"
+    "time"
+)
+
+// every room remembers its last settings.HistorySize broadcast messages
+// (questions, answers, judgements, chat) so that players joining late can
+// catch up. Only broadcasts get there, whispers and private channels never
+// do. Replayed lines are sent to the one client only and are not published
+
+type historyEntry struct {
+    // counts from 1 in every room
+    seq int
+    time time.Time
+    msg *i18n.Message
+}
+
+func (game *Game) remember(msg *i18n.Message) {
+    game.historySeq++
+    game.history = append(game.history, historyEntry{game.historySeq, time.Now(), msg})
+    if len(game.history) > settings.HistorySize {
+        game.history = game.history[len(game.history) - settings.HistorySize:]
+    }
+}
+
+// the last n entries at most
+func (game *Game) historyTail(n int) []historyEntry {
+    if n > len(game.history) {
+        n = len(game.history)
+    }
+    return game.history[len(game.history) - n:]
+}
+
+// entries with after < seq < before
+func (game *Game) historyBetween(after int, before int) []historyEntry {
+    var entries []historyEntry
+    for _, entry := range game.history {
+        if entry.seq > after && entry.seq < before {
+            entries = append(entries, entry)
+        }
+    }
+    return entries
+}
+
+func (game *Game) replay(client *Client, header *i18n.Message, entries []historyEntry) {
+    if len(entries) == 0 {
+        return
+    }
+    client.send(fmt.Sprintf("===========%s===========%c", header.In(client.lang), settings.EOL))
+    for _, entry := range entries {
+        client.send(fmt.Sprintf("%s %s%c", entry.time.Format("15:04:05"), entry.msg.In(client.lang), settings.EOL))
+    }
+}
+
+// brings a client that has just come into the room up to date
+func (game *Game) replayTail(client *Client) {
+    entries := game.historyTail(settings.HistoryReplay)
+    client.replayedFrom = game.historySeq + 1
+    if len(entries) > 0 {
+        client.replayedFrom = entries[0].seq
+    }
+    game.replay(client, i18n.M("history.earlier", game.Name), entries)
+}
+
+// replays what a resumed client has missed since old lost connection,
+// leaving out what it has been shown on joining
+func (game *Game) replayMissed(client *Client, old *Client) {
+    game.replay(client, i18n.M("history.away"), game.historyBetween(old.lastSeen, client.replayedFrom))
+}
+
+// ":history [n]"
+func (game *Game) procHistoryCmd(cmdParts []string, client *Client) {
+    n := settings.HistoryReplay
+    if len(cmdParts) > 1 {
+        var err error
+        if n, err = strconv.Atoi(cmdParts[1]); err != nil || n <= 0 {
+            game.Notify(client, "usage", ":history [number of messages]")
+            return
+        }
+    }
+    entries := game.historyTail(n)
+    if len(entries) == 0 {
+        game.Notify(client, "history.nothing")
+        return
+    }
+    game.replay(client, i18n.M("history.last", len(entries), game.Name), entries)
+}
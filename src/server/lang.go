@@ -0,0 +1,58 @@
+package server
+
+
+import (
+    "events"
+    "i18n"
+    "settings"
+    "strings"
+)
+
+// players read server messages in the language they pick with ":lang",
+// see the i18n package. Logs and game events stay in English
+
+// sends the message to everyone in the room, each in their language
+func (game *Game) Announce(id string, args ...interface{}) {
+    game.announce(i18n.M(id, args...))
+}
+
+// sends the message to the client in its language
+func (game *Game) Notify(client *Client, id string, args ...interface{}) {
+    game.say(i18n.M(id, args...), client)
+}
+
+// turns down what the client did, programs on the event feed learn why
+// without reading the text
+func (game *Game) refuse(client *Client, id string, args ...interface{}) {
+    game.Notify(client, id, args...)
+    game.publish(events.Refused, client.name, "", id)
+}
+
+func (game *Game) announce(msg *i18n.Message) {
+    for _, client := range game.GetClientsOnline() {
+        client.send(msg.In(client.lang) + string(settings.EOL))
+    }
+    game.remember(msg)
+    game.server.metrics.broadcasts.With(game.Name).Inc()
+    game.publish(events.Broadcast, "", "", msg.String() + string(settings.EOL))
+}
+
+func (game *Game) say(msg *i18n.Message, client *Client) {
+    client.send(msg.In(client.lang) + string(settings.EOL))
+    game.publish(events.Whisper, "", client.name, msg.String() + string(settings.EOL))
+}
+
+// ":lang [language]"
+func (game *Game) procLangCmd(cmdParts []string, client *Client) {
+    languages := strings.Join(i18n.Languages(), ", ")
+    if len(cmdParts) == 1 {
+        game.Notify(client, "lang.current", client.lang, languages)
+        return
+    }
+    if len(cmdParts) != 2 || !i18n.Supported(cmdParts[1]) {
+        game.Notify(client, "lang.unknown", languages)
+        return
+    }
+    client.lang = cmdParts[1]
+    game.Notify(client, "lang.set", i18n.M("lang." + client.lang))
+}
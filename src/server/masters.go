@@ -0,0 +1,243 @@
+package server
+
+
+import (
+    "events"
+    "fmt"
+    "protocol"
+    "settings"
+    "sort"
+    "strings"
+    "time"
+)
+
+// the master may hand the game over (":master give <name>"), step down
+// (":master resign") and appoint co-masters allowed to run some of the
+// master's commands (":comaster <name> [rights]"). A master who loses
+// connection keeps the seat for settings.MasterGrace, then a co-master
+// takes over or, if there's none, the countdown is paused until someone
+// runs ":master"
+
+const masterUsage = ":master | :master give <name|#id> | :master resign"
+const coMasterUsage = ":comaster <name|#id> [right...] | :comaster remove <name|#id>"
+
+// commands co-masters may be allowed to run, by right
+var masterRights = map[string][]string{
+    "timer": {":time", ":pause", ":resume"},
+    "judge": {":accept", ":reject"},
+    "mode": {":game", ":chat", ":reset"},
+    "questions": {":pack", ":next"},
+    "moderate": {":kick", ":mute", ":unmute", ":teamchat"},
+}
+
+// right needed to run a command
+var rightOf = make(map[string]string)
+
+func init() {
+    for right, commands := range masterRights {
+        for _, command := range commands {
+            rightOf[command] = right
+        }
+    }
+}
+
+type rights map[string]bool
+
+func parseRights(names []string) (rights, error) {
+    r := make(rights)
+    for _, name := range names {
+        if _, ok := masterRights[name]; !ok {
+            return nil, fmt.Errorf("no such right '%s', try some of: %s", name, strings.Join(rightNames(), ", "))
+        }
+        r[name] = true
+    }
+    return r, nil
+}
+
+func rightNames() []string {
+    var names []string
+    for name := range masterRights {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+func (r rights) String() string {
+    var names []string
+    for name := range r {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return strings.Join(names, ", ")
+}
+
+// true if client may run the master's command: the master runs them all,
+// co-masters the ones their rights cover
+func (game *Game) allowed(client *Client, command string) bool {
+    if game.master == client {
+        return true
+    }
+    return game.coMasters[client.id][rightOf[command]]
+}
+
+func (game *Game) isCoMaster(client *Client) bool {
+    _, ok := game.coMasters[client.id]
+    return ok
+}
+
+// makes client the master, how tells the way it has happened
+func (game *Game) crown(client *Client, how string) {
+    delete(game.coMasters, client.id)
+    game.SetMaster(client)
+    game.publish(events.Master, client.name, "", how)
+    if e, ok := game.questionInfo(); ok && client.feed != nil {
+        client.send(protocol.EncodeEvent(e))
+    }
+}
+
+func (game *Game) procMasterCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        if err := game.takeMaster(client); err == nil {
+            return
+        }
+        if game.master.disconnected {
+            game.Notify(client, "master.lost.wait")
+        } else {
+            game.Notify(client, "master.has")
+        }
+        return
+    }
+    if game.master != client {
+        game.Notify(client, "only.give")
+        return
+    }
+    switch {
+    case cmdParts[1] == "resign" && len(cmdParts) == 2:
+        game.dropMaster()
+    case cmdParts[1] == "give" && len(cmdParts) > 2:
+        ref := strings.Join(cmdParts[2:], " ")
+        target, err := game.findClient(ref)
+        if err != nil {
+            game.Notify(client, "find.failed", ref, err)
+            return
+        }
+        if target == client {
+            game.Notify(client, "master.already")
+            return
+        }
+        if game.match != nil && game.match.plays(target) {
+            game.Notify(client, "master.team")
+            return
+        }
+        client.setMaster(false)
+        game.crown(target, "given")
+        game.Announce("master.given", client.name, target.GetName())
+    default:
+        game.Notify(client, "usage", masterUsage)
+    }
+}
+
+func (game *Game) procCoMasterCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        var names []string
+        for _, cl := range game.GetClientsOnline() {
+            if r, ok := game.coMasters[cl.id]; ok {
+                names = append(names, fmt.Sprintf("%s (%s)", cl.name, r))
+            }
+        }
+        if len(names) == 0 {
+            game.Notify(client, "comaster.none")
+        } else {
+            game.Notify(client, "comaster.list", strings.Join(names, ", "))
+        }
+        return
+    }
+    if game.master != client {
+        game.Notify(client, "only.comaster")
+        return
+    }
+    if cmdParts[1] == "remove" {
+        ref := strings.Join(cmdParts[2:], " ")
+        target, err := game.findClient(ref)
+        if err != nil {
+            game.Notify(client, "find.failed", ref, err)
+            return
+        }
+        if !game.isCoMaster(target) {
+            game.Notify(client, "comaster.not", target.name)
+            return
+        }
+        delete(game.coMasters, target.id)
+        game.publish(events.CoMaster, client.name, target.name, "revoked")
+        game.Announce("comaster.gone", target.name)
+        return
+    }
+    // the rights are the trailing words naming ones, the name goes before them
+    args := cmdParts[1:]
+    i := len(args)
+    for i > 1 && masterRights[args[i - 1]] != nil {
+        i--
+    }
+    names := args[i:]
+    if len(names) == 0 {
+        names = strings.Fields(settings.CoMasterRights)
+    }
+    r, err := parseRights(names)
+    if err != nil || len(r) == 0 {
+        game.Notify(client, "usage", coMasterUsage)
+        return
+    }
+    ref := strings.Join(args[:i], " ")
+    target, err := game.findClient(ref)
+    if err != nil {
+        game.Notify(client, "find.failed", ref, err)
+        return
+    }
+    if target == client {
+        game.Notify(client, "comaster.self")
+        return
+    }
+    if game.match != nil && game.match.plays(target) {
+        game.Notify(client, "comaster.team")
+        return
+    }
+    if game.coMasters == nil {
+        game.coMasters = make(map[int]rights)
+    }
+    game.coMasters[target.id] = r
+    game.publish(events.CoMaster, client.name, target.name, r.String())
+    game.Announce("comaster.new", target.name, r)
+}
+
+// the master's connection is gone, the seat is kept for a while
+func (game *Game) masterLost(master *Client) {
+    grace := settings.MasterGrace
+    game.Announce("master.lost", seconds(grace))
+    time.AfterFunc(grace, func() {
+        // fails if the room has been shut down by then, nothing to do
+        game.Do(func() { game.masterFailover(master) })
+    })
+}
+
+// the grace period is over: a co-master takes over, otherwise the game
+// waits for a new master
+func (game *Game) masterFailover(master *Client) {
+    if game.master != master || !master.disconnected {
+        // back or replaced meanwhile
+        return
+    }
+    game.master = nil
+    for _, cl := range game.GetClientsOnline() {
+        if game.isCoMaster(cl) {
+            game.crown(cl, "failover")
+            game.Announce("master.failover", cl.GetName())
+            return
+        }
+    }
+    game.publish(events.Master, "", master.name, "lost")
+    if game.time && !game.paused {
+        game.pause("")
+    }
+    game.Announce("master.vacant")
+}
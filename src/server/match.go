@@ -0,0 +1,285 @@
+package server
+
+
+import (
+    "encoding/json"
+    "events"
+    "i18n"
+    "settings"
+    "strconv"
+    "strings"
+    "tournament"
+)
+
+// a match between two teams of the room: only they can press, the server
+// counts questions, announces the score after every judgement and the
+// winner at the end, then goes back to chat mode. A tie after the last
+// question is played out with extra questions
+
+const matchUsage = ":match <team> vs <team> [questions=N] [target=N] | :match stop"
+
+type match struct {
+    // client ids, they survive resumed sessions
+    teams [2]int
+    // names as of the last time the teams were seen
+    names [2]string
+    scores [2]int
+    // questions to play, the last one may be followed by extra ones on a tie
+    questions int
+    // points to win right away, 0 if none
+    target int
+    // questions over so far
+    played int
+    // the tournament and its match being played, nil if none
+    tournament *tournament.Tournament
+    tournamentMatch int
+}
+
+type MatchInfo struct {
+    Teams [2]string `json:"teams"`
+    Scores [2]int `json:"scores"`
+    // the question being played, counting from 1
+    Question int `json:"question"`
+    Questions int `json:"questions"`
+    Target int `json:"target,omitempty"`
+    // set once the match is over
+    Winner string `json:"winner,omitempty"`
+}
+
+// index of the client in the match, -1 if it doesn't play
+func (m *match) team(client *Client) int {
+    for i, id := range m.teams {
+        if client.id == id {
+            return i
+        }
+    }
+    return -1
+}
+
+func (m *match) plays(client *Client) bool {
+    return m.team(client) >= 0
+}
+
+// index of the winner once the match is over, -1 while it goes on
+func (m *match) winner() int {
+    for i, score := range m.scores {
+        if m.target > 0 && score >= m.target {
+            return i
+        }
+    }
+    if m.questions == 0 || m.played < m.questions || m.scores[0] == m.scores[1] {
+        return -1
+    }
+    if m.scores[0] > m.scores[1] {
+        return 0
+    }
+    return 1
+}
+
+func (game *Game) matchInfo() *MatchInfo {
+    m := game.match
+    if m == nil {
+        return nil
+    }
+    game.refreshTeamNames()
+    return &MatchInfo{Teams: m.names, Scores: m.scores, Question: m.played + 1,
+                      Questions: m.questions, Target: m.target}
+}
+
+func (game *Game) refreshTeamNames() {
+    for i, id := range game.match.teams {
+        if client := game.clientById(id); client != nil {
+            game.match.names[i] = client.name
+        }
+    }
+}
+
+func (game *Game) matchScore() *i18n.Message {
+    game.refreshTeamNames()
+    m := game.match
+    return i18n.M("match.score", m.names[0], m.scores[0], m.scores[1], m.names[1])
+}
+
+func (game *Game) procMatchCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) == 1 {
+        if game.match == nil {
+            game.Notify(client, "match.none")
+            return
+        }
+        m := game.match
+        if m.questions > 0 && m.played >= m.questions {
+            game.Notify(client, "match.status.extra", m.played + 1 - m.questions, game.matchScore())
+        } else if m.questions > 0 {
+            game.Notify(client, "match.status.of", m.played + 1, m.questions, game.matchScore())
+        } else {
+            game.Notify(client, "match.status", m.played + 1, game.matchScore())
+        }
+        return
+    }
+    if game.master != client {
+        game.Notify(client, "only.match")
+        return
+    }
+    if len(cmdParts) == 2 && cmdParts[1] == "stop" {
+        if game.match == nil {
+            game.Notify(client, "match.none")
+            return
+        }
+        game.abortMatch()
+        return
+    }
+    if game.match != nil {
+        game.Notify(client, "match.already")
+        return
+    }
+    m := &match{questions: settings.MatchQuestions, target: settings.MatchTarget}
+    var names []string
+    for _, part := range cmdParts[1:] {
+        option := strings.SplitN(part, "=", 2)
+        if len(option) == 1 {
+            names = append(names, part)
+            continue
+        }
+        value, err := strconv.Atoi(option[1])
+        if err != nil || value < 0 {
+            game.Notify(client, "match.number", option[0], option[1])
+            return
+        }
+        switch option[0] {
+        case "questions":
+            m.questions = value
+        case "target":
+            m.target = value
+        default:
+            game.Notify(client, "usage", matchUsage)
+            return
+        }
+    }
+    teams := strings.Split(strings.Join(names, " "), " vs ")
+    if len(teams) != 2 || m.questions == 0 && m.target == 0 {
+        game.Notify(client, "usage", matchUsage)
+        return
+    }
+    for i, name := range teams {
+        team, err := game.findClient(name)
+        if err != nil {
+            game.Notify(client, "match.no_team", name, err)
+            return
+        }
+        if team.isMaster {
+            game.Notify(client, "match.master")
+            return
+        }
+        m.teams[i] = team.id
+        m.names[i] = team.name
+    }
+    if m.teams[0] == m.teams[1] {
+        game.Notify(client, "match.itself")
+        return
+    }
+    game.startMatch(m)
+}
+
+func (game *Game) publishMatch(kind events.Kind, info *MatchInfo) {
+    data, _ := json.Marshal(info)
+    actor := ""
+    if game.master != nil {
+        actor = game.master.name
+    }
+    game.publish(kind, actor, info.Winner, string(data))
+}
+
+func (game *Game) startMatch(m *match) {
+    game.match = m
+    game.round = 0
+    game.Reset()
+    game.gameMode = true
+    game.publishMatch(events.MatchStart, game.matchInfo())
+    goal := i18n.M("match.goal.questions", m.questions)
+    if m.target > 0 && m.questions > 0 {
+        goal = i18n.M("match.goal.both", m.target, goal)
+    } else if m.target > 0 {
+        goal = i18n.M("match.goal.target", m.target)
+    }
+    game.Announce("match.start", m.names[0], m.names[1], goal)
+    game.nextMatchQuestion()
+}
+
+// announces the next question of the match, reads it out if there's a pack
+func (game *Game) nextMatchQuestion() {
+    m := game.match
+    number := m.played + 1
+    if m.questions == 0 {
+        game.Announce("match.question", number)
+    } else if number > m.questions {
+        game.Announce("match.extra", number - m.questions)
+    } else {
+        game.Announce("match.question.of", number, m.questions)
+    }
+    if game.master != nil && game.pack != nil && game.question + 1 < len(game.pack.Questions) {
+        game.askNextQuestion(game.master)
+    }
+}
+
+// a match team has been judged, points are the ones it got
+func (game *Game) matchJudged(client *Client, points int) {
+    m := game.match
+    if m == nil {
+        return
+    }
+    if team := m.team(client); team >= 0 {
+        m.scores[team] += points
+    }
+    game.announce(game.matchScore())
+}
+
+// the question has been answered or the time is out
+func (game *Game) matchQuestionOver() {
+    m := game.match
+    if m == nil {
+        return
+    }
+    m.played++
+    if winner := m.winner(); winner >= 0 {
+        game.endMatch(winner)
+        return
+    }
+    game.nextMatchQuestion()
+}
+
+func (game *Game) endMatch(winner int) {
+    info := game.matchInfo()
+    info.Question = game.match.played
+    info.Winner = info.Teams[winner]
+    loser := 1 - winner
+    game.publishMatch(events.MatchEnd, info)
+    game.Announce("match.won", info.Teams[winner], info.Teams[loser], info.Scores[winner], info.Scores[loser])
+    m := game.match
+    game.match = nil
+    // the result can't be taken back
+    game.forgetUndo()
+    game.toLobby()
+    game.server.matchEnded(game, m, winner)
+}
+
+func (game *Game) abortMatch() {
+    m := game.match
+    game.match = nil
+    // nor can the match be brought back
+    game.forgetUndo()
+    game.Announce("match.stopped")
+    game.toLobby()
+    game.server.matchAborted(m)
+}
+
+// back to chatting once the match is over
+func (game *Game) toLobby() {
+    game.Reset()
+    game.gameMode = false
+    actor := ""
+    if game.master != nil {
+        actor = game.master.name
+    }
+    game.publish(events.Mode, actor, "", "chat")
+    game.Announce("mode.chat")
+}
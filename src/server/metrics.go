@@ -0,0 +1,62 @@
+package server
+
+
+import (
+    "metrics"
+    "strconv"
+)
+
+type serverMetrics struct {
+    presses *metrics.CounterVec
+    falseStarts *metrics.CounterVec
+    timeouts *metrics.CounterVec
+    broadcasts *metrics.CounterVec
+    dropped *metrics.CounterVec
+    // from reading the press off the socket to broadcasting "your answer?"
+    pressLatency *metrics.HistogramVec
+    rtt *metrics.HistogramVec
+}
+
+func newServerMetrics(server *Server) *serverMetrics {
+    registry := server.Metrics
+    m := &serverMetrics{
+        presses: registry.NewCounter("brain_presses_total",
+            "Button presses accepted", "room"),
+        falseStarts: registry.NewCounter("brain_false_starts_total",
+            "Button presses before the countdown", "room"),
+        timeouts: registry.NewCounter("brain_timeouts_total",
+            "Countdowns ended with nobody pressing", "room"),
+        broadcasts: registry.NewCounter("brain_messages_broadcast_total",
+            "Messages broadcast to a room", "room"),
+        dropped: registry.NewCounter("brain_messages_dropped_total",
+            "Messages not delivered because a client's send queue was full", "room"),
+        pressLatency: registry.NewHistogram("brain_press_broadcast_latency_seconds",
+            "Time from reading a press to broadcasting it", metrics.DefBuckets, "room"),
+        rtt: registry.NewHistogram("brain_client_rtt_seconds",
+            "Round trip time measured by pings",
+            []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5}, "room"),
+    }
+    registry.NewGaugeFunc("brain_clients_connected", "Clients online per room",
+        []string{"room"},
+        func(emit func(float64, ...string)) {
+            for _, game := range server.Rooms() {
+                var online int
+                if game.Do(func() { online = len(game.GetClientsOnline()) }) == nil {
+                    emit(float64(online), game.Name)
+                }
+            }
+        })
+    registry.NewGaugeFunc("brain_client_send_queue_depth",
+        "Messages waiting to be written to a client",
+        []string{"room", "client"},
+        func(emit func(float64, ...string)) {
+            for _, game := range server.Rooms() {
+                game.Do(func() {
+                    for _, client := range game.GetClientsOnline() {
+                        emit(float64(len(client.outcoming)), game.Name, strconv.Itoa(client.id))
+                    }
+                })
+            }
+        })
+    return m
+}
@@ -0,0 +1,66 @@
+package server
+
+
+import (
+    "i18n"
+    "settings"
+    "strings"
+    "unicode"
+    "unicode/utf8"
+)
+
+// player names may have several words but must be unique in the room
+// (letter case aside), printable and not look like something the server
+// adds itself, such as the "(master) " prefix of GetName
+
+var ErrNameEmpty error = i18n.M("name.empty")
+var ErrNameTaken error = i18n.M("name.taken")
+var ErrNameBanned error = i18n.M("name.banned")
+var ErrNameReserved error = i18n.M("name.reserved")
+var ErrNameControl error = i18n.M("name.control")
+
+// compared ignoring the letter case
+var reservedPrefixes = []string{
+    "(master)", "(broadcast)", "(whisper)", "(system)", "anonymous player", "#",
+}
+
+// the name with runs of spaces squeezed, or why it can't be taken
+func (game *Game) checkName(name string, client *Client) (string, error) {
+    name = strings.Join(strings.Fields(name), " ")
+    if name == "" {
+        return "", ErrNameEmpty
+    }
+    if !utf8.ValidString(name) || strings.IndexFunc(name, unicode.IsControl) >= 0 {
+        return "", ErrNameControl
+    }
+    if utf8.RuneCountInString(name) > settings.MaxNameLength {
+        return "", i18n.M("name.too_long", settings.MaxNameLength)
+    }
+    lower := strings.ToLower(name)
+    for _, prefix := range reservedPrefixes {
+        if strings.HasPrefix(lower, prefix) {
+            return "", ErrNameReserved
+        }
+    }
+    if game.server.bans.find(name, true) != nil {
+        return "", ErrNameBanned
+    }
+    if game.nameTaken(name, client) {
+        return "", ErrNameTaken
+    }
+    return name, nil
+}
+
+// true if someone in the room but client goes by that name, players who
+// have lost connection keep theirs as long as they can come back
+func (game *Game) nameTaken(name string, client *Client) bool {
+    for _, cl := range game.Clients {
+        if cl.disconnected && !cl.resumable() {
+            continue
+        }
+        if cl != client && strings.EqualFold(cl.name, name) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,181 @@
+package server
+
+
+import (
+    "fmt"
+    "plugin"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// house rules: plugins get told what happens in a room through Hooks and
+// may change the points of a judgement and send messages of their own.
+// They register under a name with RegisterPlugin, compiled in or from Go
+// plugins opened with OpenPlugins, and settings.Plugins picks the ones
+// every room plays by, e.g. "quickbonus within=3; lastround factor=2".
+// Hooks run inside the room, the same way commands do
+
+type Hooks interface {
+    // a player has come into the room
+    OnJoin(game *Game, client *Client)
+    // the player's press has been taken, after is the time since the
+    // countdown has been started or resumed
+    OnPress(game *Game, client *Client, after time.Duration)
+    OnAnswer(game *Game, client *Client, answer string)
+    // nobody has pressed in time
+    OnTimeout(game *Game)
+    // the master has judged an answer, the hook may change the points
+    OnJudgement(game *Game, j *Judgement)
+    // the question of the round is over, answered or timed out
+    OnRoundEnd(game *Game, round int)
+}
+
+// hooks keeping state of their own between calls implement it as well,
+// so that ":undo" takes the state back together with the scores
+type StatefulHooks interface {
+    // a copy of the state, changing the hooks later must not change it
+    SaveState() interface{}
+    RestoreState(state interface{})
+}
+
+// hooks doing nothing, plugins embed it to implement only the ones they need
+type NoHooks struct{}
+
+func (NoHooks) OnJoin(game *Game, client *Client) {}
+func (NoHooks) OnPress(game *Game, client *Client, after time.Duration) {}
+func (NoHooks) OnAnswer(game *Game, client *Client, answer string) {}
+func (NoHooks) OnTimeout(game *Game) {}
+func (NoHooks) OnJudgement(game *Game, j *Judgement) {}
+func (NoHooks) OnRoundEnd(game *Game, round int) {}
+
+type Judgement struct {
+    Player *Client
+    Correct bool
+    // what the player's score changes by, hooks may change it
+    Points int
+    // see OnPress
+    PressedAfter time.Duration
+}
+
+// what follows the plugin's name in settings.Plugins, key=value words
+type Options map[string]string
+
+func (o Options) Int(key string, def int) (int, error) {
+    value, ok := o[key]
+    if !ok {
+        return def, nil
+    }
+    n, err := strconv.Atoi(value)
+    if err != nil {
+        return 0, fmt.Errorf("%s should be an integer, not '%s'", key, value)
+    }
+    return n, nil
+}
+
+// makes the hooks of a room, every room gets its own
+type PluginFactory func(options Options) (Hooks, error)
+
+var plugins = make(map[string]PluginFactory)
+
+func RegisterPlugin(name string, factory PluginFactory) error {
+    if _, ok := plugins[name]; ok {
+        return fmt.Errorf("plugin %s is registered already", name)
+    }
+    plugins[name] = factory
+    return nil
+}
+
+func Plugins() []string {
+    var names []string
+    for name := range plugins {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// opens Go plugins built with -buildmode=plugin, they register their
+// hooks and commands in init
+func OpenPlugins(paths []string) error {
+    for _, path := range paths {
+        if _, err := plugin.Open(path); err != nil {
+            return fmt.Errorf("cannot open plugin %s: %s", path, err)
+        }
+    }
+    return nil
+}
+
+type pluginSpec struct {
+    name string
+    options Options
+}
+
+// "name key=value ...; name ..."
+func parsePluginSpecs(config string) ([]pluginSpec, error) {
+    var specs []pluginSpec
+    for _, entry := range strings.Split(config, ";") {
+        words := strings.Fields(entry)
+        if len(words) == 0 {
+            continue
+        }
+        if _, ok := plugins[words[0]]; !ok {
+            return nil, fmt.Errorf("no such plugin '%s', try some of: %s", words[0], strings.Join(Plugins(), ", "))
+        }
+        spec := pluginSpec{name: words[0], options: make(Options)}
+        for _, word := range words[1:] {
+            kv := strings.SplitN(word, "=", 2)
+            if len(kv) != 2 {
+                return nil, fmt.Errorf("plugin %s: '%s' should be key=value", words[0], word)
+            }
+            spec.options[kv[0]] = kv[1]
+        }
+        specs = append(specs, spec)
+    }
+    return specs, nil
+}
+
+// the hooks of a new room, the ones failing to start are left out
+func (server *Server) newHooks(room string) []Hooks {
+    var hooks []Hooks
+    for _, spec := range server.plugins {
+        h, err := plugins[spec.name](spec.options)
+        if err != nil {
+            server.log.Error("cannot start plugin", "plugin", spec.name, "room", room, "err", err)
+            continue
+        }
+        hooks = append(hooks, h)
+    }
+    return hooks
+}
+
+func (game *Game) runHooks(hook func(h Hooks)) {
+    for _, h := range game.hooks {
+        hook(h)
+    }
+}
+
+// the round being played, counts from 1 since the match has started or
+// the pack has been loaded
+func (game *Game) Round() int {
+    return game.round + 1
+}
+
+// rounds the game is going to have, 0 if nobody knows
+func (game *Game) Rounds() int {
+    if game.match != nil {
+        return game.match.questions
+    }
+    if game.pack != nil {
+        return len(game.pack.Questions)
+    }
+    return 0
+}
+
+// the question has been answered or the time is out
+func (game *Game) roundOver() {
+    game.round++
+    game.runHooks(func(h Hooks) { h.OnRoundEnd(game, game.round) })
+    game.matchQuestionOver()
+}
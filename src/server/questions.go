@@ -0,0 +1,93 @@
+package server
+
+
+import (
+    "encoding/json"
+    "events"
+    "pack"
+    "path/filepath"
+    "settings"
+)
+
+// question packs: the master loads one with ":pack <file>" and goes
+// through it with ":next". Answers and comments are only sent to the master
+// until ":reveal"
+
+func (game *Game) procPackCmd(cmdParts []string, client *Client) {
+    // only files from the pack directory
+    path := filepath.Join(settings.PackDir, filepath.Base(cmdParts[1]))
+    p, err := pack.Load(path)
+    if err != nil {
+        game.log.Warn("cannot load pack", "path", path, "err", err)
+        game.Notify(client, "pack.failed", err)
+        return
+    }
+    game.pack = p
+    game.question = -1
+    game.revealed = false
+    game.round = 0
+    game.Announce("pack.loaded", p.Title, len(p.Questions))
+}
+
+func (game *Game) currentQuestion() *pack.Question {
+    if game.pack == nil || game.question < 0 || game.question >= len(game.pack.Questions) {
+        return nil
+    }
+    return &game.pack.Questions[game.question]
+}
+
+func (game *Game) procNextCmd(client *Client) {
+    if game.pack == nil {
+        game.Notify(client, "pack.first")
+        return
+    }
+    if game.question + 1 >= len(game.pack.Questions) {
+        game.Notify(client, "pack.over")
+        return
+    }
+    game.askNextQuestion(client)
+}
+
+// reads out the next question of the pack, there must be one
+func (game *Game) askNextQuestion(client *Client) {
+    game.Reset()
+    game.question++
+    game.revealed = false
+    q := game.currentQuestion()
+    game.publish(events.Question, client.name, "", q.Text)
+    game.Announce("question.text", q.Number, q.Text)
+    game.publishQuestionInfo()
+}
+
+// tells everyone the answer, the display shows it from now on
+func (game *Game) procRevealCmd(client *Client) {
+    q := game.currentQuestion()
+    if q == nil {
+        game.Notify(client, "question.none")
+        return
+    }
+    game.revealed = true
+    game.publish(events.Reveal, client.name, "", q.Answer)
+    game.Announce("question.answer", q.Answer)
+    if q.Comment != "" {
+        game.Announce("question.comment", q.Comment)
+    }
+}
+
+// answer and comments of the current question, for masters only
+func (game *Game) questionInfo() (events.Event, bool) {
+    q := game.currentQuestion()
+    if q == nil {
+        return events.Event{}, false
+    }
+    data, _ := json.Marshal(q)
+    e := events.New(events.QuestionInfo, game.Name, "", "", string(data))
+    e.Private = true
+    return e, true
+}
+
+func (game *Game) publishQuestionInfo() {
+    if e, ok := game.questionInfo(); ok && game.server != nil {
+        game.server.Events.Publish(e)
+    }
+}
@@ -0,0 +1,87 @@
+package server
+
+
+import (
+    "events"
+)
+
+// players move between rooms with ":join <room>", e.g. to play a
+// tournament match. Rooms are created on first use and live until the
+// server is shut down
+
+func (game *Game) procJoinCmd(cmdParts []string, client *Client) {
+    if cmdParts[1] == game.Name {
+        game.Notify(client, "room.already", game.Name)
+        return
+    }
+    if game.match != nil && game.match.plays(client) {
+        game.Notify(client, "room.match")
+        return
+    }
+    target := game.server.openRoom(cmdParts[1])
+    name := client.name
+    // the target room is looked at and changed in its own loop only
+    go func() {
+        taken := false
+        if target.Do(func() { taken = target.nameTaken(name, client) }) != nil {
+            return
+        }
+        if taken {
+            game.Do(func() { game.Notify(client, "room.name_taken", target.Name) })
+            return
+        }
+        game.server.moveClient(client, game, target)
+    }()
+}
+
+// takes the client out of one room and puts it into another, false if
+// it has left meanwhile. Each room is changed in its own loop, so this
+// must not be called from inside one
+func (server *Server) moveClient(client *Client, from *Game, to *Game) bool {
+    moved := false
+    from.Do(func() {
+        if from.clientById(client.id) == client {
+            from.leave(client, to)
+            moved = true
+        }
+    })
+    return moved && to.Do(func() { to.arrive(client) }) == nil
+}
+
+// the part of a move done by the room the client leaves
+func (game *Game) leave(client *Client, target *Game) {
+    if game.master == client {
+        game.dropMaster()
+    }
+    delete(game.coMasters, client.id)
+    if game.buttonPressed == client {
+        game.buttonPressed = nil
+    }
+    if game.lastAnswered == client {
+        game.lastAnswered = nil
+    }
+    for i, cl := range game.Clients {
+        if cl == client {
+            game.Clients = append(game.Clients[:i], game.Clients[i+1:]...)
+            break
+        }
+    }
+    game.publish(events.Leave, client.name, "", target.Name)
+    game.Announce("room.left", client.GetName(), target.Name)
+    client.log.Info("moved", "to", target.Name)
+    client.setRoom(target)
+}
+
+// the part of a move done by the room the client comes to
+func (game *Game) arrive(client *Client) {
+    client.canAnswer = game.match == nil || game.match.plays(client)
+    client.log = game.log.With("client", client.id, "addr", client.conn.RemoteAddr())
+    game.Clients = append(game.Clients, client)
+    game.publish(events.Join, client.name, "", client.conn.RemoteAddr().String())
+    game.replayTail(client)
+    game.Announce("room.joined", client.GetName(), game.Name)
+    game.runHooks(func(h Hooks) { h.OnJoin(game, client) })
+    if client.feed != nil {
+        game.catchUp(client)
+    }
+}
@@ -0,0 +1,126 @@
+package server
+
+
+import (
+    "crypto/rand"
+    "encoding/hex"
+    "events"
+    "fmt"
+    "protocol"
+    "settings"
+    "time"
+)
+
+// sessions let a client that lost its connection come back as the same
+// player: the server hands out a token on join and a reconnected client
+// sends ":session <token>" to take its name, score and role back
+
+func newSessionToken() string {
+    buf := make([]byte, 16)
+    if _, err := rand.Read(buf); err != nil {
+        // never happens on supported platforms
+        panic(err)
+    }
+    return hex.EncodeToString(buf)
+}
+
+func (game *Game) sendSession(client *Client) {
+    client.send(fmt.Sprintf("%s %s%c", protocol.Session, client.token, settings.EOL))
+}
+
+// a client that has lost connection and may still come back, see SessionTimeout
+func (client *Client) resumable() bool {
+    return client.disconnected && client.token != "" &&
+           time.Since(client.disconnectedAt) <= settings.SessionTimeout
+}
+
+// the client the token was issued to in any room and the room, if its
+// session has not expired. Each room is looked at in its own loop, so
+// this must not be called from inside one
+func (server *Server) sessionOwner(token string, except *Client) (*Client, *Game) {
+    for _, game := range server.Rooms() {
+        var owner *Client
+        expired := false
+        game.Do(func() {
+            for _, old := range game.Clients {
+                if old == except || old.token != token {
+                    continue
+                }
+                owner = old
+                expired = old.disconnected && !old.resumable()
+                return
+            }
+        })
+        if expired {
+            return nil, nil
+        }
+        if owner != nil {
+            return owner, game
+        }
+    }
+    return nil, nil
+}
+
+func (game *Game) procSessionCmd(cmdParts []string, client *Client) {
+    if len(cmdParts) != 2 {
+        game.Notify(client, "usage", protocol.ResumeSession + " <token>")
+        return
+    }
+    token := cmdParts[1]
+    go func() {
+        old, room := game.server.sessionOwner(token, client)
+        if old == nil {
+            game.Do(func() { game.Notify(client, "session.expired") })
+            return
+        }
+        // back to the room the player has been in
+        if room != game && !game.server.moveClient(client, game, room) {
+            return
+        }
+        room.Do(func() {
+            // unless resumed by another connection meanwhile
+            if old.token == token {
+                room.resumeSession(client, old)
+            }
+        })
+    }()
+}
+
+// client takes the place of old, both are in this room
+func (game *Game) resumeSession(client *Client, old *Client) {
+    if !old.disconnected {
+        // the old connection is most likely dead but we haven't noticed yet
+        old.log.Info("connection taken over by a resumed session")
+        old.Exit()
+    }
+    anonymous := client.GetName()
+    client.id = old.id
+    client.name = old.name
+    client.score = old.score
+    client.canAnswer = old.canAnswer
+    client.team = old.team
+    client.wantsTeam = old.wantsTeam
+    client.lang = old.lang
+    // no way to get rid of a mute by reconnecting
+    client.muted = old.muted
+    client.mutedUntil = old.mutedUntil
+    client.token = old.token
+    // make sure the old record can't be resumed once more
+    old.token = ""
+    if game.buttonPressed == old {
+        game.buttonPressed = client
+    }
+    if game.lastAnswered == old {
+        game.lastAnswered = client
+    }
+    if old.isMaster && (game.master == nil || game.master == old) {
+        game.SetMaster(client)
+    }
+    client.log = game.log.With("client", client.id, "addr", client.conn.RemoteAddr())
+    client.log.Info("session resumed", "was", anonymous)
+    game.sendSession(client)
+    game.replayMissed(client, old)
+    game.publish(events.Rename, anonymous, client.name, "resumed")
+    game.Announce("session.back", anonymous, client.GetName())
+    go game.server.scheduleMatches()
+}
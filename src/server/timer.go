@@ -0,0 +1,97 @@
+package server
+
+
+import (
+    "events"
+    "settings"
+    "strconv"
+    "time"
+)
+
+// the round countdown. Every start bumps timerGen so that a timer
+// stopped too late to be cancelled can be told from the current one
+
+func (game *Game) startTimer(d time.Duration) {
+    game.stopTimer()
+    game.time = true
+    game.paused = false
+    game.timerStarted = time.Now()
+    game.deadline = game.timerStarted.Add(d)
+    gen := game.timerGen
+    game.timer = time.AfterFunc(d, func() {
+        select {
+        case game.timeout <- gen:
+        case <-game.done:
+        }
+    })
+    if warning := settings.CueWarning; warning > 0 && d > warning {
+        game.warningTimer = time.AfterFunc(d - warning, func() {
+            // fails if the room has been shut down by then, nothing to warn
+            game.Do(func() {
+                if gen == game.timerGen {
+                    game.cue(events.CueWarning, nil)
+                }
+            })
+        })
+    }
+}
+
+func (game *Game) stopTimer() {
+    if game.timer != nil {
+        game.timer.Stop()
+        game.timer = nil
+    }
+    if game.warningTimer != nil {
+        game.warningTimer.Stop()
+        game.warningTimer = nil
+    }
+    game.timerGen++
+}
+
+// how much time the countdown has left, 0 if it doesn't run
+func (game *Game) timeLeft() time.Duration {
+    if !game.time {
+        return 0
+    }
+    if game.paused {
+        return game.remaining
+    }
+    left := time.Until(game.deadline)
+    if left < 0 {
+        return 0
+    }
+    return left
+}
+
+// whole seconds, rounded up the way people count
+func seconds(d time.Duration) int {
+    return int((d + time.Second - 1) / time.Second)
+}
+
+func (game *Game) procPauseCmd(client *Client) {
+    if !game.time || game.paused {
+        game.Notify(client, "timer.not_running")
+        return
+    }
+    game.pause(client.name)
+}
+
+// puts the running countdown on hold, actor is who has done it
+func (game *Game) pause(actor string) {
+    game.remaining = game.timeLeft()
+    game.stopTimer()
+    game.paused = true
+    game.publish(events.Pause, actor, "", strconv.Itoa(seconds(game.remaining)))
+    game.Announce("timer.paused", seconds(game.remaining))
+}
+
+func (game *Game) procResumeCmd(client *Client) {
+    if !game.paused {
+        game.Notify(client, "timer.not_paused")
+        return
+    }
+    game.startTimer(game.remaining)
+    game.publish(events.TimerStart, client.name, "", strconv.Itoa(seconds(game.remaining)))
+    game.cue(events.CueStart, nil)
+    game.Announce("timer.seconds", seconds(game.remaining))
+}
@@ -0,0 +1,346 @@
+package server
+
+
+import (
+    "fmt"
+    "i18n"
+    "settings"
+    "strconv"
+    "strings"
+    "tournament"
+)
+
+// a tournament across rooms: masters register teams and start a bracket,
+// the server sends both teams of every playable match to a room of its
+// own ("match-1", "match-2", ...), records the result once the match is
+// over and brings the teams back to the lobby. Other rooms are looked at
+// and changed only through their Do, from a goroutine of its own: two
+// rooms waiting on each other would never finish
+
+var errTournamentStarted error = i18n.M("tournament.err.started")
+
+const tournamentUsage = ":tournament add|remove <team> | start <format> [questions=N] [target=N] | schedule | stop"
+
+func (game *Game) procTournamentCmd(cmdParts []string, client *Client) {
+    server := game.server
+    if len(cmdParts) == 1 {
+        server.tmu.Lock()
+        running := server.tournament != nil
+        teams := strings.Join(server.entrants, ", ")
+        server.tmu.Unlock()
+        if running {
+            game.Notify(client, "tournament.on")
+        } else if teams == "" {
+            game.Notify(client, "tournament.no_teams")
+        } else {
+            game.Notify(client, "tournament.teams", teams)
+        }
+        return
+    }
+    if game.master != client {
+        game.Notify(client, "only.tournament")
+        return
+    }
+    arg := strings.Join(cmdParts[2:], " ")
+    switch cmdParts[1] {
+    case "add", "remove":
+        if arg == "" {
+            game.Notify(client, "usage", tournamentUsage)
+            return
+        }
+        if err := server.register(arg, cmdParts[1] == "add"); err != nil {
+            game.Notify(client, "tournament." + cmdParts[1] + "_failed", arg, err)
+            return
+        }
+        if cmdParts[1] == "add" {
+            go server.announce("tournament.added", arg)
+        } else {
+            go server.announce("tournament.removed", arg)
+        }
+    case "start":
+        game.startTournament(cmdParts[2:], client)
+    case "schedule":
+        go server.scheduleMatches()
+    case "stop":
+        server.tmu.Lock()
+        running := server.tournament != nil
+        server.tournament = nil
+        server.tmu.Unlock()
+        if !running {
+            game.Notify(client, "tournament.none")
+            return
+        }
+        go server.announce("tournament.stopped")
+    default:
+        game.Notify(client, "usage", tournamentUsage)
+    }
+}
+
+func (server *Server) register(team string, add bool) error {
+    server.tmu.Lock()
+    defer server.tmu.Unlock()
+    if server.tournament != nil {
+        return errTournamentStarted
+    }
+    for i, entrant := range server.entrants {
+        if entrant != team {
+            continue
+        }
+        if add {
+            return i18n.M("tournament.err.registered")
+        }
+        server.entrants = append(server.entrants[:i], server.entrants[i+1:]...)
+        return nil
+    }
+    if !add {
+        return i18n.M("tournament.err.not_registered")
+    }
+    server.entrants = append(server.entrants, team)
+    return nil
+}
+
+func (game *Game) startTournament(args []string, client *Client) {
+    if len(args) == 0 {
+        game.Notify(client, "usage", tournamentUsage)
+        return
+    }
+    format, err := tournament.ParseFormat(args[0])
+    if err != nil {
+        game.Notify(client, "tournament.format", args[0], tournament.Formats)
+        return
+    }
+    questions, target := settings.MatchQuestions, settings.MatchTarget
+    for _, arg := range args[1:] {
+        option := strings.SplitN(arg, "=", 2)
+        value := -1
+        if len(option) == 2 {
+            value, err = strconv.Atoi(option[1])
+        }
+        if err != nil || value < 0 || option[0] != "questions" && option[0] != "target" {
+            game.Notify(client, "usage", tournamentUsage)
+            return
+        }
+        if option[0] == "questions" {
+            questions = value
+        } else {
+            target = value
+        }
+    }
+    if questions == 0 && target == 0 {
+        game.Notify(client, "usage", tournamentUsage)
+        return
+    }
+    server := game.server
+    server.tmu.Lock()
+    if server.tournament != nil {
+        server.tmu.Unlock()
+        game.Notify(client, "tournament.start_failed", errTournamentStarted)
+        return
+    }
+    t, err := tournament.New(format, server.entrants)
+    if err != nil {
+        server.tmu.Unlock()
+        game.Notify(client, "tournament.start_failed", err)
+        return
+    }
+    server.tournament = t
+    server.entrants = nil
+    server.matchQuestions, server.matchTarget = questions, target
+    server.tmu.Unlock()
+    go func() {
+        server.announce("tournament.started", format, i18n.M("tournament.teams.n", len(t.Teams)),
+                        i18n.M("tournament.matches.n", len(t.Matches)))
+        server.scheduleMatches()
+    }()
+}
+
+// announces to the lobby in its loop, must not be called from a room loop
+func (server *Server) announce(id string, args ...interface{}) {
+    lobby := server.lobby()
+    lobby.Do(func() { lobby.Announce(id, args...) })
+}
+
+func (game *Game) procBracketCmd(client *Client) {
+    server := game.server
+    server.tmu.Lock()
+    if server.tournament == nil {
+        server.tmu.Unlock()
+        game.Notify(client, "tournament.none")
+        return
+    }
+    msgs := server.tournament.Messages()
+    server.tmu.Unlock()
+    for _, msg := range msgs {
+        game.say(msg, client)
+    }
+}
+
+// the only online client with that name free to play and its room
+func (server *Server) findTeam(name string) (*Client, *Game) {
+    var found *Client
+    var foundIn *Game
+    for _, game := range server.Rooms() {
+        var client *Client
+        var err error = ErrNoSuchClient
+        busy := false
+        game.Do(func() {
+            client, err = game.clientByName(name)
+            busy = err == nil && game.match != nil && game.match.plays(client)
+        })
+        if err == ErrAmbiguousName || err == nil && found != nil {
+            return nil, nil
+        }
+        if err == nil {
+            if busy {
+                return nil, nil
+            }
+            found, foundIn = client, game
+        }
+    }
+    return found, foundIn
+}
+
+// the first "match-N" room without a match, m is put there to keep it
+func (server *Server) freeMatchRoom(m *match) *Game {
+    for i := 1; ; i++ {
+        room := server.openRoom(fmt.Sprintf("match-%d", i))
+        free := false
+        room.Do(func() {
+            if room.match == nil {
+                room.match = m
+                free = true
+            }
+        })
+        if free {
+            return room
+        }
+    }
+}
+
+// sends the teams of every playable match to a room and starts the match
+// there, matches whose teams are not online wait. Must not be called from
+// a room loop, the rooms are looked at and changed through their Do
+func (server *Server) scheduleMatches() {
+    server.smu.Lock()
+    defer server.smu.Unlock()
+    server.tmu.Lock()
+    t := server.tournament
+    questions, target := server.matchQuestions, server.matchTarget
+    var playable []tournament.Match
+    if t != nil {
+        for _, m := range t.Playable() {
+            playable = append(playable, *m)
+        }
+    }
+    server.tmu.Unlock()
+    for _, m := range playable {
+        var teams [2]*Client
+        var rooms [2]*Game
+        for i, name := range m.Teams {
+            teams[i], rooms[i] = server.findTeam(name)
+        }
+        if teams[0] == nil || teams[1] == nil {
+            continue
+        }
+        started := &match{questions: questions, target: target,
+                          tournament: t, tournamentMatch: m.Id}
+        for i, team := range teams {
+            started.teams[i] = team.id
+            started.names[i] = m.Teams[i]
+        }
+        room := server.freeMatchRoom(started)
+        server.tmu.Lock()
+        stopped := server.tournament != t
+        if !stopped {
+            t.Assign(m.Id, room.Name)
+        }
+        server.tmu.Unlock()
+        if stopped {
+            room.Do(func() {
+                if room.match == started {
+                    room.match = nil
+                }
+            })
+            return
+        }
+        for i, team := range teams {
+            if rooms[i] != room {
+                server.moveClient(team, rooms[i], room)
+            }
+        }
+        server.announce("tournament.scheduled", m.Id, m.Teams[0], m.Teams[1], room.Name)
+        room.Do(func() {
+            // unless stopped meanwhile
+            if room.match == started {
+                room.startMatch(started)
+            }
+        })
+    }
+}
+
+// records the result of a tournament match and brings its teams back,
+// called in the loop of the match room
+func (server *Server) matchEnded(game *Game, m *match, winner int) {
+    if m.tournament == nil {
+        return
+    }
+    server.tmu.Lock()
+    t := server.tournament
+    if t != m.tournament {
+        // stopped meanwhile
+        server.tmu.Unlock()
+        return
+    }
+    err := t.Record(m.tournamentMatch, winner, m.scores)
+    result := t.Match(m.tournamentMatch).Message()
+    champion := t.Champion()
+    server.tmu.Unlock()
+    if err != nil {
+        game.log.Warn("cannot record match result", "match", m.tournamentMatch, "err", err)
+        return
+    }
+    lobby := server.lobby()
+    var back []*Client
+    for _, id := range m.teams {
+        if client := game.clientById(id); client != nil && game != lobby {
+            game.leave(client, lobby)
+            back = append(back, client)
+        }
+    }
+    go func() {
+        lobby.Do(func() {
+            for _, client := range back {
+                lobby.arrive(client)
+            }
+            lobby.announce(result)
+            if champion != "" {
+                lobby.Announce("tournament.champion", champion)
+            }
+        })
+        if champion == "" {
+            server.scheduleMatches()
+        }
+    }()
+}
+
+// the match will be played again
+func (server *Server) matchAborted(m *match) {
+    if m.tournament == nil {
+        return
+    }
+    server.tmu.Lock()
+    defer server.tmu.Unlock()
+    if server.tournament == m.tournament {
+        m.tournament.Unassign(m.tournamentMatch)
+    }
+}
+
+// a snapshot of the tournament, false if there's none
+func (server *Server) Tournament() (tournament.Info, bool) {
+    server.tmu.Lock()
+    defer server.tmu.Unlock()
+    if server.tournament == nil {
+        return tournament.Info{}, false
+    }
+    return server.tournament.Info(), true
+}
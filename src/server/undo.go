@@ -0,0 +1,238 @@
+package server
+
+
+import (
+    "events"
+    "pack"
+    "reflect"
+    "strconv"
+    "time"
+)
+
+// master's state-changing commands can be taken back with ":undo" and
+// done again with ":redo". The game is snapshotted around every such
+// command, undoing restores the snapshot taken before it and tells the
+// players what has changed. A restored countdown goes on with the time it
+// had left when the snapshot was taken. Once a match is over or stopped
+// nothing done before can be undone
+
+// older entries are forgotten
+const maxUndo = 100
+
+type snapshot struct {
+    // per client id, online clients only
+    scores map[int]int
+    canAnswer map[int]bool
+    pressedAfter map[int]time.Duration
+    // client ids, 0 if none
+    buttonPressed int
+    lastAnswered int
+    gameMode bool
+    time bool
+    paused bool
+    deadline time.Time
+    started time.Time
+    // what the countdown had left when the snapshot was taken
+    left time.Duration
+    pack *pack.Pack
+    question int
+    revealed bool
+    match *match
+    falseStart falseStartPolicy
+    round int
+    // of the hooks keeping state, by their index
+    hooks map[int]interface{}
+}
+
+type undoEntry struct {
+    // the command as typed
+    command string
+    before snapshot
+    after snapshot
+}
+
+func clientId(client *Client) int {
+    if client == nil {
+        return 0
+    }
+    return client.id
+}
+
+func (game *Game) snapshot() snapshot {
+    s := snapshot{scores: make(map[int]int), canAnswer: make(map[int]bool),
+                  pressedAfter: make(map[int]time.Duration),
+                  buttonPressed: clientId(game.buttonPressed),
+                  lastAnswered: clientId(game.lastAnswered),
+                  gameMode: game.gameMode, time: game.time, paused: game.paused,
+                  deadline: game.deadline, started: game.timerStarted, left: game.timeLeft(),
+                  pack: game.pack, question: game.question, revealed: game.revealed,
+                  falseStart: game.falseStart,
+                  round: game.round}
+    for _, client := range game.GetClientsOnline() {
+        s.scores[client.id] = client.score
+        s.canAnswer[client.id] = client.canAnswer
+        s.pressedAfter[client.id] = client.pressedAfter
+    }
+    for i, h := range game.hooks {
+        if state, ok := h.(StatefulHooks); ok {
+            if s.hooks == nil {
+                s.hooks = make(map[int]interface{})
+            }
+            s.hooks[i] = state.SaveState()
+        }
+    }
+    if game.match != nil {
+        m := *game.match
+        s.match = &m
+    }
+    return s
+}
+
+// true if nothing but the time has passed between the snapshots
+func (s snapshot) same(other snapshot) bool {
+    s.left, other.left = 0, 0
+    return reflect.DeepEqual(s, other)
+}
+
+// pushes the command on the undo stack if it has changed anything and
+// the stack has not been forgotten meanwhile
+func (game *Game) recordUndo(command string, before snapshot, generation int) {
+    after := game.snapshot()
+    if generation != game.undoGen || before.same(after) {
+        return
+    }
+    game.undo = append(game.undo, undoEntry{command, before, after})
+    if len(game.undo) > maxUndo {
+        game.undo = game.undo[1:]
+    }
+    game.redo = nil
+}
+
+// nothing done before can be taken back, e.g. once a match is over
+func (game *Game) forgetUndo() {
+    game.undo = nil
+    game.redo = nil
+    game.undoGen++
+}
+
+func (game *Game) procUndoCmd(client *Client, redo bool) {
+    from, to := &game.undo, &game.redo
+    if redo {
+        from, to = to, from
+    }
+    if len(*from) == 0 {
+        if redo {
+            game.Notify(client, "undo.nothing_redo")
+        } else {
+            game.Notify(client, "undo.nothing")
+        }
+        return
+    }
+    entry := (*from)[len(*from) - 1]
+    *from = (*from)[:len(*from) - 1]
+    *to = append(*to, entry)
+    target, kind, id := entry.before, events.Undo, "undo.undone"
+    if redo {
+        target, kind, id = entry.after, events.Redo, "undo.redone"
+    }
+    current := game.snapshot()
+    game.restore(target)
+    game.publish(kind, client.name, "", entry.command)
+    game.Announce(id, client.GetName(), entry.command)
+    game.announceRestore(current, target)
+}
+
+func (game *Game) restore(s snapshot) {
+    for _, client := range game.GetClientsOnline() {
+        if score, ok := s.scores[client.id]; ok {
+            client.score = score
+            client.canAnswer = s.canAnswer[client.id]
+            client.pressedAfter = s.pressedAfter[client.id]
+        }
+    }
+    // presses waiting to be settled belong to what is undone
+    game.contenders = nil
+    game.pressGen++
+    game.buttonPressed = game.clientById(s.buttonPressed)
+    game.lastAnswered = game.clientById(s.lastAnswered)
+    game.gameMode = s.gameMode
+    game.stopTimer()
+    game.time = false
+    game.paused = false
+    game.deadline = time.Time{}
+    if s.time && s.paused {
+        game.time = true
+        game.paused = true
+        game.remaining = s.left
+    } else if s.time {
+        // presses are timed from the start of the countdown as they were
+        game.startTimer(s.left)
+        game.timerStarted = game.timerStarted.Add(s.left - s.deadline.Sub(s.started))
+    }
+    game.pack = s.pack
+    game.question = s.question
+    game.revealed = s.revealed
+    game.match = nil
+    if s.match != nil {
+        m := *s.match
+        game.match = &m
+    }
+    game.falseStart = s.falseStart
+    game.round = s.round
+    for i, state := range s.hooks {
+        game.hooks[i].(StatefulHooks).RestoreState(state)
+    }
+}
+
+// tells players what the correction has changed and brings event
+// subscribers up to date
+func (game *Game) announceRestore(from snapshot, to snapshot) {
+    for _, client := range game.GetClientsOnline() {
+        old, ok := from.scores[client.id]
+        if ok && old != client.score {
+            game.Announce("undo.score", client.GetName(), old, client.score)
+        }
+    }
+    mode := "chat"
+    if game.gameMode {
+        mode = "game"
+    }
+    game.publish(events.Mode, "", "", mode)
+    if from.gameMode != to.gameMode {
+        if game.gameMode {
+            game.Announce("mode.game")
+        } else {
+            game.Announce("mode.chat")
+        }
+    }
+    left := seconds(game.timeLeft())
+    switch {
+    case to.time && !to.paused && left == 0:
+        // the timeout is on its way
+    case to.time && to.paused:
+        game.publish(events.Pause, "", "", strconv.Itoa(left))
+        game.Announce("timer.paused", left)
+    case to.time:
+        game.publish(events.TimerStart, "", "", strconv.Itoa(left))
+        game.Announce("timer.seconds", left)
+    case from.time:
+        game.Announce("timer.cancelled")
+    }
+    if game.buttonPressed != nil && from.buttonPressed != to.buttonPressed {
+        game.publish(events.Press, game.buttonPressed.name, "", "")
+        game.Announce("press.answer", game.buttonPressed.GetName())
+    }
+    if from.question != to.question || from.pack != to.pack {
+        if q := game.currentQuestion(); q != nil {
+            game.publish(events.Question, "", "", q.Text)
+            game.Announce("question.back", q.Number, q.Text)
+            game.publishQuestionInfo()
+        }
+    }
+    if game.match != nil && (from.match == nil || from.match.scores != to.match.scores) {
+        game.announce(game.matchScore())
+    }
+    if from.falseStart != to.falseStart {
+        game.Announce("falsestart.rule", game.falseStart)
+    }
+}
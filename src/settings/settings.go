@@ -1,10 +1,97 @@
 package settings
 
 
+import "time"
+
 var SERVER string = "127.0.0.1"
 var PORT int = 9999
 var EOL byte = '\n'
+// name of the room clients join on connect
+var DefaultRoom string = "main"
 
 // game relevant
 // default timeout in seconds
 var RoundTimeout int = 5
+// a match ends after that many questions, the one with more points wins
+var MatchQuestions int = 12
+// or once a team has that many points, 0 to play all the questions
+var MatchTarget int = 0
+// what a press before the countdown costs, see ":falsestart"
+var FalseStart string = "lockout"
+// where ":pack <file>" looks for question packs
+var PackDir string = "packs"
+// how long a disconnected master keeps the seat before a co-master takes over
+var MasterGrace time.Duration = 30 * time.Second
+// the countdown warns that long before it ends, 0 for no warning
+var CueWarning time.Duration = 10 * time.Second
+// what co-masters may do unless told otherwise, see ":comaster"
+var CoMasterRights string = "timer"
+// language of server messages for new players, see ":lang"
+var Language string = "en"
+// house rules every room plays by, "name key=value ...; name ...", see
+// server/plugins.go
+var Plugins string = ""
+// Go plugins with more of them, paths separated by spaces
+var PluginFiles string = ""
+// player names have at most that many characters
+var MaxNameLength int = 32
+
+// flood protection: lines a second and bursts of chat, presses and
+// commands per client, a zero rate turns the limit off
+var ChatRate float64 = 1
+var ChatBurst int = 5
+var PressRate float64 = 5
+var PressBurst int = 10
+var CommandRate float64 = 5
+var CommandBurst int = 30
+// how far back a press may be dated with ":press <ms>"
+var MaxPressAge time.Duration = time.Second
+// bridges of hardware buttons say ":bridge <token>" with it, empty for
+// no bridges
+var BridgeToken string = ""
+// while a bridge is in the room, presses that close to the first one are
+// ordered by when they have been made
+var PressWindow time.Duration = 100 * time.Millisecond
+// longer lines are dropped
+var MaxLineLength int = 1024
+// a client with that many dropped lines within a minute gets muted
+var FloodMuteAfter int = 10
+var FloodMuteFor time.Duration = 30 * time.Second
+
+// broadcasts every room remembers and how many of them players coming in see
+var HistorySize int = 500
+var HistoryReplay int = 20
+
+// messages waiting to be sent to a client, when full new ones are dropped
+var SendQueueSize int = 256
+
+// client reconnect backoff: starts with ReconnectMin, doubles up to ReconnectMax
+var ReconnectMin time.Duration = 500 * time.Millisecond
+var ReconnectMax time.Duration = 30 * time.Second
+
+// how long a disconnected player can come back as the same player
+var SessionTimeout time.Duration = 10 * time.Minute
+
+// how often clients are pinged to measure round trip time
+var PingInterval time.Duration = 5 * time.Second
+
+// banned addresses and names are kept there, empty to keep them in memory
+var BanFile string = "bans.json"
+
+// address of the HTTP admin API, e.g. "127.0.0.1:9998", empty to disable
+var ADMIN string = ""
+// if set, admin requests must carry "Authorization: Bearer <token>"
+var AdminToken string = ""
+
+// address of the big screen pages for the projector, e.g. ":8080",
+// empty to disable
+var DISPLAY string = ""
+
+// logging: level is one of debug, info, warn, error; format is text or json
+var LogLevel string = "info"
+var LogFormat string = "text"
+// empty means stdout
+var LogFile string = ""
+// rotate the log file once it's bigger than that, 0 to never rotate
+var LogMaxSize int64 = 10 * 1024 * 1024
+var LogBackups int = 5
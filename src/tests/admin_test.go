@@ -0,0 +1,164 @@
+package tests
+
+import (
+    "admin"
+    "bufio"
+    "encoding/json"
+    "events"
+    "fmt"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "net/url"
+    "protocol"
+    "server"
+    "settings"
+    "strings"
+    "testing"
+    "time"
+)
+
+func getRoom(api *httptest.Server, t *testing.T) server.RoomInfo {
+    var room server.RoomInfo
+    resp, err := http.Get(api.URL + "/rooms/" + settings.DefaultRoom)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    if err := json.NewDecoder(resp.Body).Decode(&room); err != nil {
+        t.Fatal(err)
+    }
+    return room
+}
+
+func clientId(room server.RoomInfo, name string) int {
+    for _, client := range room.Clients {
+        if client.Name == name {
+            return client.Id
+        }
+    }
+    return 0
+}
+
+func TestAdminAPI(t *testing.T) {
+    s, _ := startServer()
+    api := httptest.NewServer(admin.New(s, ""))
+    defer api.Close()
+    connM := enter("Master", true, t)
+    defer disconnect(enter("Team1", false, t))
+    assert("(broadcast) ===========Game Mode On===========",
+           getResponse(connM, ":game"), t)
+    room := getRoom(api, t)
+    assert("game", room.Mode, t)
+    assert("Master", room.Master, t)
+    assert("2", fmt.Sprint(len(room.Clients)), t)
+    id := clientId(room, "Team1")
+    // admin actions look the same as master's commands to the players
+    resp, _ := http.PostForm(fmt.Sprintf("%s/rooms/%s/clients/%d/rename",
+                                         api.URL, settings.DefaultRoom, id),
+                             url.Values{"name": {"Team2"}})
+    assert("200", fmt.Sprint(resp.StatusCode), t)
+    assert("(broadcast) Team1 is now known as Team2", waitForData("(broadcast)"), t)
+    resp, _ = http.Post(fmt.Sprintf("%s/rooms/%s/clients/%d/master",
+                                    api.URL, settings.DefaultRoom, id), "", nil)
+    assert("409", fmt.Sprint(resp.StatusCode), t)
+    resp, _ = http.Post(fmt.Sprintf("%s/rooms/%s/clients/%d/kick",
+                                    api.URL, settings.DefaultRoom, id), "", nil)
+    assert("200", fmt.Sprint(resp.StatusCode), t)
+    assert("Team2", waitForEvent(events.Leave).Actor, t)
+    assert("1", fmt.Sprint(len(getRoom(api, t).Clients)), t)
+    resp, _ = http.Post(api.URL + "/rooms/nosuchroom/reset", "", nil)
+    assert("404", fmt.Sprint(resp.StatusCode), t)
+    // a reset is the master's :reset
+    waitForData("(broadcast) Team2 has been kicked")
+    getResponse(connM, ":time 10")
+    resp, _ = http.Post(api.URL + "/rooms/" + settings.DefaultRoom + "/reset", "", nil)
+    assert("200", fmt.Sprint(resp.StatusCode), t)
+    assert("(whisper) ======Game reset======", waitForAnyData(), t)
+    assert("(broadcast) (master) Master has undone ':reset'", getResponse(connM, ":undo"), t)
+    getResponse(connM, ":chat")
+    resp, _ = http.Post(api.URL + "/rooms/" + settings.DefaultRoom + "/reset", "", nil)
+    assert("409", fmt.Sprint(resp.StatusCode), t)
+    stopServer(s)
+}
+
+// round trips are measured while the admin API reads them
+func TestRTT(t *testing.T) {
+    defer func(d time.Duration) { settings.PingInterval = d }(settings.PingInterval)
+    settings.PingInterval = 20 * time.Millisecond
+    s, _ := startServer()
+    api := httptest.NewServer(admin.New(s, ""))
+    defer api.Close()
+    conn := enter("Team1", false, t)
+    defer disconnect(conn)
+    go func() {
+        reader := bufio.NewReader(conn)
+        for {
+            line, err := reader.ReadString(settings.EOL)
+            if err != nil {
+                return
+            }
+            if strings.HasPrefix(line, protocol.Ping) {
+                fmt.Fprintf(conn, "%s%s", protocol.Pong, strings.TrimPrefix(line, protocol.Ping))
+            }
+        }
+    }()
+    deadline := time.Now().Add(waitTimeout)
+    for getRoom(api, t).Clients[0].RTT == 0 {
+        if time.Now().After(deadline) {
+            t.Fatal("No round trip has been measured")
+        }
+        time.Sleep(10 * time.Millisecond)
+    }
+    stopServer(s)
+}
+
+func TestJudgement(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    getResponse(connM, ":game")
+    assert("(whisper) Only master can judge answers!", getResponse(conn1, ":accept"), t)
+    assert("(whisper) Nothing to judge yet", getResponse(connM, ":accept"), t)
+    getResponse(connM, ":time 10")
+    getResponse(conn1, "\n")
+    getResponse(conn1, "41")
+    assert("(broadcast) Team1 is wrong", getResponse(connM, ":reject"), t)
+    getResponse(conn2, "\n")
+    getResponse(conn2, "42")
+    assert("(broadcast) Team2 is right! Score: 3", getResponse(connM, ":accept 3"), t)
+    stopServer(s)
+}
+
+func TestMetrics(t *testing.T) {
+    s, _ := startServer()
+    api := httptest.NewServer(admin.New(s, ""))
+    defer api.Close()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    getResponse(connM, ":game")
+    getResponse(conn1, "\n")
+    getResponse(connM, ":time 10")
+    getResponse(conn2, "\n")
+    // the answer is processed after the press has been fully accounted
+    getResponse(conn2, "42")
+    resp, err := http.Get(api.URL + "/metrics")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    body, _ := ioutil.ReadAll(resp.Body)
+    for _, expected := range []string{
+        `brain_clients_connected{room="main"} 3`,
+        `brain_presses_total{room="main"} 1`,
+        `brain_false_starts_total{room="main"} 1`,
+        `brain_press_broadcast_latency_seconds_count{room="main"} 1`,
+        `# TYPE brain_client_send_queue_depth gauge`} {
+        if !strings.Contains(string(body), expected) {
+            t.Errorf("Expected '%s' in metrics:\n%s", expected, body)
+        }
+    }
+    stopServer(s)
+}
@@ -0,0 +1,79 @@
+package tests
+
+import (
+    "fmt"
+    "io/ioutil"
+    "net"
+    "os"
+    "path/filepath"
+    "regexp"
+    "settings"
+    "strings"
+    "testing"
+)
+
+// id of the player as shown by :who
+func whoId(conn net.Conn, name string, t *testing.T) string {
+    who := getResponse(conn, ":who")
+    m := regexp.MustCompile(`#(\d+) ` + name + ` `).FindStringSubmatch(who)
+    if m == nil {
+        t.Fatalf("No id of %s in '%s'", name, who)
+    }
+    return m[1]
+}
+
+func TestKickAndBan(t *testing.T) {
+    dir, err := ioutil.TempDir("", "bans")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer os.RemoveAll(dir)
+    defer func(path string) { settings.BanFile = path }(settings.BanFile)
+    settings.BanFile = filepath.Join(dir, "bans.json")
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    defer disconnect(enter("Team2", false, t))
+    assert("(whisper) Only master can kick players!", getResponse(conn1, ":kick Team2"), t)
+    assert("(whisper) You cannot kick yourself", getResponse(connM, ":kick Master"), t)
+    id := whoId(connM, "Team2", t)
+    assert("(whisper) You have been kicked: by Master", getResponse(connM, ":kick #" + id), t)
+    assert("(broadcast) Team2 has been kicked", waitForData("(broadcast)"), t)
+    assert(fmt.Sprintf("(whisper) Cannot find '#%s': no such client", id),
+           getResponse(connM, ":kick #" + id), t)
+
+    assert("(whisper) You have been kicked: banned", getResponse(connM, ":ban Team1 1h"), t)
+    assert("(broadcast) Team1 has been kicked", waitForData("(broadcast)"), t)
+    data, err := ioutil.ReadFile(settings.BanFile)
+    if err != nil || !strings.Contains(string(data), `"Team1"`) {
+        t.Errorf("Bans are not saved: %v '%s'", err, data)
+    }
+    // the name is banned, not the address the whole table shares
+    conn2, _ := connect()
+    defer disconnect(conn2)
+    assert("(whisper) Cannot rename to 'Team1': the name is banned", getResponse(conn2, ":rename Team1"), t)
+    assert("(whisper) Cannot rename to 'team1': the name is banned", getResponse(conn2, ":rename team1"), t)
+    assert("(whisper) Banned address 127.0.0.1", getResponse(connM, ":ban 127.0.0.1"), t)
+    // everybody from there but the master is kicked
+    assert("(whisper) You have been kicked: banned", waitForAnyData(), t)
+    if kicked := waitForData("(broadcast)"); !strings.HasSuffix(kicked, "has been kicked") {
+        t.Errorf("Not the thing expected: '%s'", kicked)
+    }
+    // the tests connect from the banned address
+    conn, err := net.Dial("tcp", "127.0.0.1:9999")
+    if err != nil {
+        t.Fatal(err)
+    }
+    buf := make([]byte, 64)
+    n, _ := conn.Read(buf)
+    assert("You are banned", strings.TrimSpace(string(buf[:n])), t)
+    conn.Close()
+    assert("(whisper) Ban of 127.0.0.1 is lifted", getResponse(connM, ":unban 127.0.0.1"), t)
+    assert("(whisper) Cannot rename to 'Team1': the name is banned", getResponse(connM, ":rename Team1"), t)
+    assert("(whisper) name Team1 (by Master)",
+           regexp.MustCompile(` until [0-9:-]+ [0-9:]+`).ReplaceAllString(getResponse(connM, ":bans"), ""), t)
+    assert("(whisper) Ban of Team1 is lifted", getResponse(connM, ":unban Team1"), t)
+    assert("(whisper) Nobody is banned", getResponse(connM, ":bans"), t)
+    defer disconnect(enter("Team1", false, t))
+    stopServer(s)
+}
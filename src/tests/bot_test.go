@@ -0,0 +1,25 @@
+package tests
+
+import (
+    "bot"
+    "testing"
+    "time"
+)
+
+func TestBots(t *testing.T) {
+    s, _ := startServer()
+    cfg := bot.DefaultConfig()
+    cfg.Players = 20
+    cfg.Rounds = 2
+    cfg.RoundSeconds = 1
+    cfg.PressDelayMax = 500 * time.Millisecond
+    cfg.JoinInterval = 0
+    stats := bot.Run(cfg)
+    if stats.Errors() > 0 {
+        t.Errorf("Bots have got errors:\n%s", stats)
+    }
+    if stats.Percentile(50) == 0 {
+        t.Errorf("No press has been acknowledged:\n%s", stats)
+    }
+    stopServer(s)
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+    "client"
+    "events"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "settings"
+    "strings"
+    "syscall"
+    "testing"
+    "unsafe"
+)
+
+// a pseudo terminal standing for the serial port of a button box, returns
+// its master side and the path of the device
+func openPty(t *testing.T) (*os.File, string) {
+    ptmx, err := os.OpenFile("/dev/ptmx", os.O_RDWR, 0)
+    if err != nil {
+        t.Skipf("no pseudo terminals: %s", err)
+    }
+    var n uint32
+    if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCGPTN,
+                                      uintptr(unsafe.Pointer(&n))); errno != 0 {
+        t.Fatal(errno)
+    }
+    var unlock int32
+    if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, ptmx.Fd(), syscall.TIOCSPTLCK,
+                                      uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+        t.Fatal(errno)
+    }
+    return ptmx, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestBridge(t *testing.T) {
+    if _, err := client.ParseTeams("1=Alpha,Beta"); err == nil {
+        t.Error("a button without a team is taken")
+    }
+    teams, err := client.ParseTeams("1=Alpha, 2=Beta, 3=Beta")
+    if err != nil {
+        t.Fatal(err)
+    }
+    ptmx, device := openPty(t)
+    defer ptmx.Close()
+    input, err := client.OpenInput(device, 9600)
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer input.Close()
+
+    defer func(token string) { settings.BridgeToken = token }(settings.BridgeToken)
+    settings.BridgeToken = "secret"
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    getResponse(connM, ":game")
+    bridge := client.NewBridge("127.0.0.1", 9999, teams, "", "secret", ioutil.Discard)
+    go bridge.Run(input)
+    // two teams for three buttons
+    renamed := 0
+    for renamed < 2 {
+        data := waitForData("(broadcast)")
+        if data == "" {
+            t.Fatal("the teams have not come")
+        }
+        if strings.HasSuffix(data, "is now known as Alpha") || strings.HasSuffix(data, "is now known as Beta") {
+            renamed++
+        }
+    }
+
+    getResponse(connM, ":time 10")
+    fmt.Fprint(ptmx, "9\n3\n")
+    assert("(broadcast) Beta, your answer?", waitForAnyData(), t)
+    getResponse(connM, ":reset")
+    getResponse(connM, ":time 10")
+    fmt.Fprint(ptmx, "1 down\n")
+    assert("(broadcast) Alpha, your answer?", waitForAnyData(), t)
+
+    bridge.Close()
+    for i := 0; i < 2; i++ {
+        if waitForEvent(events.Leave).Kind != events.Leave {
+            t.Error("the teams are still there")
+        }
+    }
+    stopServer(s)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+    "testing"
+)
+
+func TestChannels(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    conn3 := enter("Team3", false, t)
+    assert("(whisper) [Team1 -> Team2] psst", getResponse(conn1, ":msg Team2 psst"), t)
+    assert("(whisper) [Team1 -> Team2] psst", waitForAnyData(), t)
+    assert("(whisper) Cannot find 'Team4': no such client", getResponse(conn1, ":msg Team4 psst"), t)
+
+    assert("(whisper) You are not in a team, join one with :team <name>", getResponse(conn1, ":t hi"), t)
+    assert("(whisper) Team1 has joined team Red Cats", getResponse(conn1, ":team Red Cats"), t)
+    // a team lets new players in itself
+    assert("(whisper) You have asked team Red Cats to let you in", getResponse(conn2, ":team Red Cats"), t)
+    assert("(whisper) Team2 asks to join team Red Cats, let them in with :team accept #3", waitForAnyData(), t)
+    assert("(whisper) You are not in a team, join one with :team <name>", getResponse(conn2, ":t hi"), t)
+    assert("(whisper) Team3 has not asked to join team Red Cats", getResponse(conn1, ":team accept Team3"), t)
+    assert("(whisper) Team2 has joined team Red Cats", getResponse(conn1, ":team accept Team2"), t)
+    waitForAnyData()
+    getResponse(conn3, ":team Blue")
+    assert("(whisper) Team Red Cats: Team1, Team2", getResponse(conn2, ":team"), t)
+    // teammates only, even in game mode
+    getResponse(connM, ":game")
+    assert("(whisper) [Team2 -> team Red Cats] it's Pushkin", getResponse(conn2, ":t it's Pushkin"), t)
+    assert("(whisper) [Team2 -> team Red Cats] it's Pushkin", waitForAnyData(), t)
+    assert("(whisper) [Team3 -> team Blue] hmm", getResponse(conn3, ":t hmm"), t)
+    assert("(whisper) Only master can turn team chat on and off!", getResponse(conn1, ":teamchat off"), t)
+    assert("(broadcast) ===========Team chat is off===========", getResponse(connM, ":teamchat off"), t)
+    assert("(whisper) Team chat is off", getResponse(conn1, ":t hi"), t)
+    getResponse(connM, ":teamchat on")
+
+    assert("(whisper) Only master and co-masters can talk there!", getResponse(conn3, ":masters hi"), t)
+    getResponse(connM, ":comaster Team3")
+    assert("(whisper) [Team3 -> masters] next question?", getResponse(conn3, ":masters next question?"), t)
+    assert("(whisper) [Team3 -> masters] next question?", waitForAnyData(), t)
+    assert("(whisper) You have left team Red Cats", getResponse(conn1, ":team leave"), t)
+    assert("(whisper) Team1 has left team Red Cats", waitForAnyData(), t)
+    stopServer(s)
+}
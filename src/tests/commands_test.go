@@ -0,0 +1,75 @@
+package tests
+
+import (
+    "server"
+    "strings"
+    "testing"
+)
+
+// a command the way plugins add them
+var errRoll = server.RegisterCommand(&server.Command{
+    Name: ":roll", Aliases: []string{":dice"}, Role: server.Master, Right: "judge",
+    Args: []server.Arg{{Name: "sides", Kind: server.Int}},
+    Run: func(game *server.Game, client *server.Client, call *server.Call) {
+        game.Broadcast(strings.Repeat("*", call.Int(0, 6)))
+    },
+})
+
+func TestCommands(t *testing.T) {
+    if errRoll != nil {
+        t.Fatalf("Cannot register :roll: %s", errRoll)
+    }
+    if err := server.RegisterCommand(&server.Command{Name: ":who"}); err == nil {
+        t.Errorf("Registered :who twice")
+    }
+    if err := server.RegisterCommand(&server.Command{Name: "roll"}); err == nil {
+        t.Errorf("Registered a command without ':'")
+    }
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    // players see the commands they may run only
+    help := getResponse(conn1, ":help")
+    if !strings.HasPrefix(help, "(whisper) Commands: ") || !strings.Contains(help, ":who") {
+        t.Errorf("Not the thing expected: '%s'", help)
+    }
+    for _, cmd := range []string{":ban", ":time", ":roll", ":session"} {
+        if strings.Contains(help, cmd + ",") {
+            t.Errorf("%s is in the help of a player: '%s'", cmd, help)
+        }
+    }
+    assert("(whisper) Type :help <command> for details", waitForAnyData(), t)
+    help = getResponse(connM, ":?")
+    if !strings.Contains(help, ":roll") || !strings.Contains(help, ":ban") {
+        t.Errorf("Not the thing expected: '%s'", help)
+    }
+    waitForAnyData()
+    assert("(whisper) Usage: :time [seconds]", getResponse(conn1, ":help time"), t)
+    assert("(whisper) Starts the countdown", waitForAnyData(), t)
+    assert("(whisper) Master and co-masters with the timer right", waitForAnyData(), t)
+    assert("(whisper) In game mode only", waitForAnyData(), t)
+    assert("(whisper) Usage: :msg <name|#id> <text...>", getResponse(conn1, ":help :msg"), t)
+    waitForAnyData()
+    assert("(whisper) Also: :tell", waitForAnyData(), t)
+    assert("(whisper) No such command: 'nope', see :help", getResponse(conn1, ":help nope"), t)
+
+    // arguments are checked before a command runs
+    assert("(broadcast) ===========Game Mode On===========", getResponse(connM, ":game"), t)
+    assert("(whisper) Bad arguments for :time: seconds should be an integer, not 'soon'. Usage: :time [seconds]",
+           getResponse(connM, ":time soon"), t)
+    assert("(whisper) Bad arguments for :pack: file is missing. Usage: :pack <file>",
+           getResponse(connM, ":pack"), t)
+    assert("(whisper) Bad arguments for :who: too many arguments: me. Usage: :who",
+           getResponse(conn1, ":who me"), t)
+    assert("(whisper) Bad arguments for :msg: text is missing. Usage: :msg <name|#id> <text...>",
+           getResponse(conn1, ":tell Master"), t)
+    assert("(whisper) [Team1 -> (master) Master] hi", getResponse(conn1, ":tell Master hi"), t)
+    waitForAnyData()
+
+    // registered commands get the same checks
+    assert("(whisper) Only master can run :roll!", getResponse(conn1, ":dice 6"), t)
+    assert("(broadcast) ***", getResponse(connM, ":dice 3"), t)
+    getResponse(connM, ":comaster Team1 judge")
+    assert("(broadcast) **", getResponse(conn1, ":roll 2"), t)
+    stopServer(s)
+}
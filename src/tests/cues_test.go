@@ -0,0 +1,51 @@
+package tests
+
+import (
+    "events"
+    "net"
+    "settings"
+    "testing"
+    "time"
+)
+
+// cues go before the text, so lines are sent without waiting for it
+func sendLine(conn net.Conn, line string) {
+    conn.Write([]byte(line + "\n"))
+}
+
+func expectCue(cue string, target string, t *testing.T) {
+    e := waitForEvent(events.Cue)
+    assert(cue, e.Payload, t)
+    assert(target, e.Target, t)
+}
+
+func TestCues(t *testing.T) {
+    defer func(d time.Duration) { settings.CueWarning = d }(settings.CueWarning)
+    settings.CueWarning = time.Second
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    getResponse(connM, ":game")
+    sendLine(conn1, "")
+    expectCue(events.CueFalseStart, "Team1", t)
+
+    getResponse(connM, ":reset")
+    sendLine(connM, ":time 2")
+    expectCue(events.CueStart, "", t)
+    expectCue(events.CueWarning, "", t)
+    expectCue(events.CueTimeout, "", t)
+
+    // no warning once the countdown has been stopped
+    sendLine(connM, ":time 2")
+    expectCue(events.CueStart, "", t)
+    sendLine(conn1, "")
+    expectCue(events.CuePress, "Team1", t)
+    assert("(broadcast) Team1, your answer?", waitForAnyData(), t)
+    getResponse(conn1, "42")
+    assert("(broadcast) Team1 is right! Score: 1", getResponse(connM, ":accept"), t)
+    time.Sleep(1500 * time.Millisecond)
+    sendLine(connM, ":time 1")
+    expectCue(events.CueStart, "", t)
+    expectCue(events.CueTimeout, "", t)
+    stopServer(s)
+}
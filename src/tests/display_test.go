@@ -0,0 +1,130 @@
+package tests
+
+import (
+    "bufio"
+    "display"
+    "encoding/json"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "net/http"
+    "net/http/httptest"
+    "os"
+    "path/filepath"
+    "settings"
+    "strings"
+    "testing"
+    "time"
+)
+
+// states as the page gets them, the raw data goes along to look into
+type screen struct {
+    state display.State
+    data string
+}
+
+func watchDisplay(body io.Reader) <-chan screen {
+    screens := make(chan screen, 64)
+    go func() {
+        defer close(screens)
+        reader := bufio.NewReader(body)
+        event := ""
+        for {
+            line, err := reader.ReadString('\n')
+            if err != nil {
+                return
+            }
+            line = strings.TrimSuffix(line, "\n")
+            if strings.HasPrefix(line, "event: ") {
+                event = strings.TrimPrefix(line, "event: ")
+            } else if strings.HasPrefix(line, "data: ") && event == "state" {
+                s := screen{data: strings.TrimPrefix(line, "data: ")}
+                json.Unmarshal([]byte(s.data), &s.state)
+                screens <- s
+            }
+        }
+    }()
+    return screens
+}
+
+// waits for a state the condition holds for, none of the ones before may
+// give the answer away
+func waitForScreen(screens <-chan screen, t *testing.T, revealed bool, cond func(display.State) bool) display.State {
+    timeout := time.After(waitTimeout)
+    for {
+        select {
+        case s, ok := <-screens:
+            if !ok {
+                t.Fatal("the display stream is over")
+            }
+            if !revealed && (strings.Contains(s.data, `"answer":`) || strings.Contains(s.data, "Deep Thought")) {
+                t.Errorf("the answer is on the screen: %s", s.data)
+            }
+            if cond(s.state) {
+                return s.state
+            }
+        case <-timeout:
+            t.Fatal("the display has not changed")
+        }
+    }
+}
+
+func TestDisplay(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "packs")
+    defer os.RemoveAll(dir)
+    ioutil.WriteFile(filepath.Join(dir, "test.json"), []byte(testPack), 0644)
+    defer func(packDir string) { settings.PackDir = packDir }(settings.PackDir)
+    settings.PackDir = dir
+    s, _ := startServer()
+    web := httptest.NewServer(display.New(s))
+    defer web.Close()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    getResponse(connM, ":game")
+
+    resp, err := http.Get(web.URL + "/display/" + settings.DefaultRoom)
+    if err != nil {
+        t.Fatal(err)
+    }
+    page, _ := ioutil.ReadAll(resp.Body)
+    resp.Body.Close()
+    if !strings.Contains(string(page), "EventSource") {
+        t.Error("not the display page")
+    }
+    resp, _ = http.Get(web.URL + "/display/nowhere/events")
+    assert("404", fmt.Sprint(resp.StatusCode), t)
+    resp.Body.Close()
+
+    resp, err = http.Get(web.URL + "/display/" + settings.DefaultRoom + "/events")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    screens := watchDisplay(resp.Body)
+    state := waitForScreen(screens, t, false, func(s display.State) bool { return true })
+    assert("game", state.Mode, t)
+    assert("[{Team1 0}]", fmt.Sprint(state.Players), t)
+
+    assert("(whisper) No question has been asked yet", getResponse(connM, ":reveal"), t)
+    getResponse(connM, ":pack test.json")
+    getResponse(connM, ":next")
+    state = waitForScreen(screens, t, false, func(s display.State) bool { return s.Question != nil })
+    assert("The answer to life, the universe and everything?", state.Question.Text, t)
+    getResponse(connM, ":time 10")
+    waitForScreen(screens, t, false, func(s display.State) bool { return s.TimerRunning })
+    getResponse(conn1, "\n")
+    waitForScreen(screens, t, false, func(s display.State) bool { return s.Pressed == "Team1" })
+    getResponse(conn1, "Forty two")
+    getResponse(connM, ":accept")
+    waitForScreen(screens, t, false, func(s display.State) bool { return s.Players[0].Score == 1 })
+
+    assert("(whisper) Only master can reveal answers!", getResponse(conn1, ":reveal"), t)
+    assert("(broadcast) Answer: 42", getResponse(connM, ":reveal"), t)
+    assert("(broadcast) Comment: Deep Thought", waitForAnyData(), t)
+    state = waitForScreen(screens, t, true, func(s display.State) bool { return s.Question.Answer != "" })
+    assert("42 Deep Thought", state.Question.Answer + " " + state.Question.Comment, t)
+    // the next question is a secret again
+    getResponse(connM, ":next")
+    waitForScreen(screens, t, false, func(s display.State) bool { return s.Question.Number == 2 })
+    stopServer(s)
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+    "events"
+    "fmt"
+    "settings"
+    "testing"
+    "time"
+)
+
+func TestFalseStartPolicies(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    // kept till the end, the garbage collector closes connections nobody refers to
+    defer disconnect(enter("Team2", false, t))
+    getResponse(connM, ":game")
+    assert("(whisper) False start rule: lockout, a false start costs the right to answer the question",
+           getResponse(conn1, ":falsestart"), t)
+    assert("(broadcast) Team1 has a false start!", getResponse(conn1, "\n"), t)
+    getResponse(connM, ":time 10")
+    assert("(whisper) You can't press button now", getResponse(conn1, "\n"), t)
+
+    assert("(broadcast) False start rule: penalty, a false start costs 2 points",
+           getResponse(connM, ":falsestart penalty 2"), t)
+    getResponse(connM, ":reset")
+    assert("(broadcast) Team1 has a false start!", getResponse(conn1, "\n"), t)
+    assert("(broadcast) Team1 loses 2 points. Score: -2", waitForAnyData(), t)
+    getResponse(connM, ":time 10")
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, "\n"), t)
+
+    getResponse(connM, ":falsestart award")
+    getResponse(connM, ":reset")
+    assert("(broadcast) Team1 has a false start!", getResponse(conn1, "\n"), t)
+    assert("(broadcast) Team2, your answer?", waitForAnyData(), t)
+
+    assert("(whisper) Usage: :falsestart lockout | award | penalty [points] | grace [ms]",
+           getResponse(connM, ":falsestart grace soon"), t)
+    getResponse(connM, ":falsestart grace 300")
+    getResponse(connM, ":reset")
+    assert("(whisper) Too early, press again", getResponse(conn1, "\n"), t)
+    getResponse(connM, ":time 10")
+    assert("(whisper) Too early, press again", getResponse(conn1, "\n"), t)
+    time.Sleep(300 * time.Millisecond)
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, "\n"), t)
+    stopServer(s)
+}
+
+// presses of a bridge are dated by the bridge
+func TestDatedPress(t *testing.T) {
+    defer func(token string) { settings.BridgeToken = token }(settings.BridgeToken)
+    settings.BridgeToken = "secret"
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    getResponse(connM, ":game")
+    getResponse(connM, ":falsestart grace 500")
+    getResponse(connM, ":time 10")
+    time.Sleep(600 * time.Millisecond)
+    // a player can't press in the past
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, ":press 400"), t)
+    assert("(whisper) Wrong bridge token", getResponse(conn2, ":bridge guess"), t)
+    assert("(whisper) Presses of this connection are dated by the bridge",
+           getResponse(conn2, ":bridge secret"), t)
+
+    getResponse(connM, ":reset")
+    getResponse(connM, ":time 10")
+    time.Sleep(600 * time.Millisecond)
+    // pressed 200ms after the start, within the grace period
+    assert("(whisper) Too early, press again", getResponse(conn2, ":press 400"), t)
+    assert("falsestart.early", waitForEvent(events.Refused).Payload, t)
+    // nobody presses before settings.MaxPressAge
+    assert("(whisper) Too early, press again", getResponse(conn2, ":press 100000"), t)
+    // presses close to each other go by their dates
+    sendLine(conn1, "")
+    sendLine(conn2, ":press 50")
+    assert("(broadcast) Team2, your answer?", waitForAnyData(), t)
+    assert("Team1", waitForEvent(events.LatePress).Actor, t)
+    stopServer(s)
+}
+
+// presses read one after another keep the times they have been read at
+func TestPressBurst(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    getResponse(connM, ":game")
+    getResponse(connM, ":time 10")
+    fmt.Fprint(conn1, "\n\n\n\n")
+    assert("(broadcast) Team1, your answer?", waitForData("(broadcast) Team1"), t)
+    stopServer(s)
+}
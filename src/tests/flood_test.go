@@ -0,0 +1,75 @@
+package tests
+
+import (
+    "fmt"
+    "ratelimit"
+    "settings"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestBucket(t *testing.T) {
+    b := ratelimit.New(2, 3)
+    now := time.Now()
+    for i := 0; i < 3; i++ {
+        if !b.AllowAt(now) {
+            t.Errorf("Burst is not allowed")
+        }
+    }
+    if b.AllowAt(now) {
+        t.Errorf("Allowed over the burst")
+    }
+    // two tokens a second
+    if !b.AllowAt(now.Add(500 * time.Millisecond)) || b.AllowAt(now.Add(500 * time.Millisecond)) {
+        t.Errorf("Bucket refills at a wrong rate")
+    }
+    if !ratelimit.New(0, 0).AllowAt(now) {
+        t.Errorf("Zero rate should mean no limit")
+    }
+}
+
+func TestFlood(t *testing.T) {
+    defer func(burst int, muteAfter int) {
+        settings.ChatBurst, settings.FloodMuteAfter = burst, muteAfter
+    }(settings.ChatBurst, settings.FloodMuteAfter)
+    settings.ChatBurst, settings.FloodMuteAfter = 2, 3
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    getResponse(conn1, "one")
+    getResponse(conn1, "two")
+    assert("(whisper) Slow down!", getResponse(conn1, "three"), t)
+    // more lines are dropped silently until the client gets muted
+    fmt.Fprint(conn1, "four\nfive\nsix\n")
+    assert("(broadcast) Team1 is muted for 30 seconds: flooding", waitForAnyData(), t)
+    assert("(broadcast) Team1 can chat again", getResponse(connM, ":unmute Team1"), t)
+    assert("(whisper) Line too long, 1024 bytes at most",
+           getResponse(connM, strings.Repeat("x", 5000)), t)
+    assert("(broadcast) Team1 is muted: by (master) Master", getResponse(connM, ":mute Team1"), t)
+    time.Sleep(2 * time.Second)
+    assert("(whisper) You are muted", getResponse(conn1, "hello?"), t)
+    assert("(whisper) Cannot find 'Team2': no such client", getResponse(connM, ":mute Team2 10"), t)
+    stopServer(s)
+}
+
+// overlong lines are judged in the room loop, however busy the room is
+func TestFloodLongLines(t *testing.T) {
+    defer func(muteAfter int) { settings.FloodMuteAfter = muteAfter }(settings.FloodMuteAfter)
+    settings.FloodMuteAfter = 3
+    s, _ := startServer()
+    defer disconnect(enter("Master", true, t))
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    go func() {
+        for i := 0; i < 20; i++ {
+            fmt.Fprintf(conn2, "busy %d\n", i)
+        }
+    }()
+    long := strings.Repeat("x", 5000) + "\n"
+    for i := 0; i < 10; i++ {
+        fmt.Fprint(conn1, long)
+    }
+    assert("(broadcast) Team1 is muted for 30 seconds: flooding", waitForData("(broadcast) Team1 is muted"), t)
+    stopServer(s)
+}
@@ -0,0 +1,70 @@
+package tests
+
+import (
+    "bufio"
+    "events"
+    "net"
+    "protocol"
+    "settings"
+    "strings"
+    "testing"
+)
+
+// replayed lines up to the one starting with last, without timestamps
+func replayed(reader *bufio.Reader, conn net.Conn, header string, last string) []string {
+    if readLine(reader, conn, "===========" + header) == "" {
+        return nil
+    }
+    var lines []string
+    for {
+        line := strings.TrimSpace(readLine(reader, conn, ""))
+        if line == "" || strings.HasPrefix(line, last) {
+            return lines
+        }
+        if len(line) > 9 {
+            line = line[9:]
+        }
+        lines = append(lines, line)
+    }
+}
+
+func TestHistory(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    reader1 := bufio.NewReader(conn1)
+    token := strings.TrimSpace(strings.TrimPrefix(
+        readLine(reader1, conn1, protocol.Session), protocol.Session))
+    getResponse(conn1, "hello all")
+    assert("(whisper) [(master) Master -> Team1] secret", getResponse(connM, ":msg Team1 secret"), t)
+    waitForAnyData()
+
+    conn2, _ := connect()
+    reader2 := bufio.NewReader(conn2)
+    lines := replayed(reader2, conn2, "Earlier in room", "'anonymous player 3' has joined us!")
+    assert("anonymous player 2 is now known as Team1", lines[len(lines) - 2], t)
+    assert("[Team1] hello all", lines[len(lines) - 1], t)
+    for _, line := range lines {
+        if strings.Contains(line, "secret") {
+            t.Errorf("A whisper has leaked into history: '%s'", line)
+        }
+    }
+    conn2.Write([]byte(":history 2\n"))
+    readLine(reader2, conn2, "===========Last 2 messages")
+    assert("[Team1] hello all", strings.TrimSpace(readLine(reader2, conn2, "")[9:]), t)
+    assert("'anonymous player 3' has joined us!", strings.TrimSpace(readLine(reader2, conn2, "")[9:]), t)
+
+    // a resumed player sees what has been missed and not replayed on joining
+    defer func(n int) { settings.HistoryReplay = n }(settings.HistoryReplay)
+    settings.HistoryReplay = 1
+    conn1.Close()
+    waitForEvent(events.Leave)
+    getResponse(connM, "while Team1 is away")
+    getResponse(connM, "still away")
+    conn1, _ = connect()
+    reader1 = bufio.NewReader(conn1)
+    conn1.Write([]byte(protocol.ResumeSession + " " + token + "\n"))
+    lines = replayed(reader1, conn1, "While you were away", "anonymous player 4 is back")
+    assert("[(master) Master] while Team1 is away", strings.Join(lines, " | "), t)
+    stopServer(s)
+}
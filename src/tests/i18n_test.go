@@ -0,0 +1,51 @@
+package tests
+
+import (
+    "bufio"
+    "i18n"
+    "strings"
+    "testing"
+)
+
+func TestCatalogs(t *testing.T) {
+    for _, lang := range i18n.Languages() {
+        if missing := i18n.Missing(lang); len(missing) > 0 {
+            t.Errorf("No %s translation for %v", lang, missing)
+        }
+    }
+    plurals := map[int]string{1: "1 секунда", 2: "2 секунды", 5: "5 секунд", 11: "11 секунд",
+                              21: "21 секунда", 24: "24 секунды"}
+    for n, expected := range plurals {
+        assert("===========" + expected + "===========", i18n.M("timer.seconds", n).In("ru"), t)
+    }
+    assert("===========1 second===========", i18n.M("timer.seconds", 1).String(), t)
+    assert("Team1 is right! Score: 3", i18n.M("judge.right", "Team1", 3).In("xx"), t)
+    assert("Cannot find 'x': no such client",
+           i18n.M("find.failed", "x", i18n.M("err.no_such_client")).String(), t)
+    assert("Не удалось найти 'x': нет такого игрока",
+           i18n.M("find.failed", "x", i18n.M("err.no_such_client")).In("ru"), t)
+    assert("[Team1 -> команда Red] hi", i18n.M("channel.team", "Team1", "Red", "hi").In("ru"), t)
+    assert("имя Team1 (от Master)",
+           i18n.M("ban.entry.name", "Team1", i18n.Text(""), i18n.M("ban.by", "Master")).In("ru"), t)
+}
+
+func TestLang(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    reader1 := bufio.NewReader(conn1)
+    assert("(whisper) Usage: :lang <language>, one of: en, ru", getResponse(conn1, ":lang xx"), t)
+    // the player reads Russian, the rest of the room and the events stay English
+    assert("(whisper) Server messages are in Russian now", getResponse(conn1, ":lang ru"), t)
+    readLine(reader1, conn1, "Теперь сообщения сервера на русском")
+    assert("(broadcast) ===========Game Mode On===========", getResponse(connM, ":game"), t)
+    line := readLine(reader1, conn1, "===========")
+    assert("===========Режим игры===========", strings.TrimSpace(line), t)
+    assert("(broadcast) ===========5 seconds===========", getResponse(connM, ":time 5"), t)
+    line = readLine(reader1, conn1, "===========")
+    assert("===========5 секунд===========", strings.TrimSpace(line), t)
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, ""), t)
+    line = readLine(reader1, conn1, "Team1")
+    assert("Team1, ваш ответ?", strings.TrimSpace(line), t)
+    stopServer(s)
+}
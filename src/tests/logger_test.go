@@ -0,0 +1,51 @@
+package tests
+
+import (
+    "bytes"
+    "encoding/json"
+    "io/ioutil"
+    "logger"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestLoggerFields(t *testing.T) {
+    var out bytes.Buffer
+    log := logger.New(&out, logger.Info, true).With("room", "main")
+    log.Debug("hidden")
+    log.With("client", 1).Warn("slow client", "rtt", "2s")
+    var record map[string]interface{}
+    if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+        t.Fatalf("%s: '%s'", err, out.String())
+    }
+    assert("warn", record["level"].(string), t)
+    assert("slow client", record["msg"].(string), t)
+    assert("main", record["room"].(string), t)
+    assert("2s", record["rtt"].(string), t)
+    out.Reset()
+    logger.New(&out, logger.Debug, false).Info("joined", "name", "Team 1")
+    if !strings.HasSuffix(out.String(), `INFO  joined name="Team 1"` + "\n") {
+        t.Errorf("Unexpected text record '%s'", out.String())
+    }
+}
+
+func TestLogRotation(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "brainlog")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "server.log")
+    file, err := logger.OpenRotating(path, 10, 2)
+    if err != nil {
+        t.Fatal(err)
+    }
+    for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+        file.Write([]byte(line))
+    }
+    file.Close()
+    for name, expected := range map[string]string{
+        "server.log": "fourth\n", "server.log.1": "third\n", "server.log.2": "second\n"} {
+        data, _ := ioutil.ReadFile(filepath.Join(dir, name))
+        assert(expected, string(data), t)
+    }
+}
@@ -0,0 +1,50 @@
+package tests
+
+import (
+    "settings"
+    "testing"
+    "time"
+)
+
+func TestMasterHandover(t *testing.T) {
+    defer func(grace time.Duration) { settings.MasterGrace = grace }(settings.MasterGrace)
+    settings.MasterGrace = time.Second
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    assert("(whisper) Only master can appoint co-masters!", getResponse(conn1, ":comaster Team1"), t)
+    assert("(broadcast) Team1 is a co-master now: timer", getResponse(connM, ":comaster Team1"), t)
+    assert("(whisper) Co-masters: Team1 (timer)", getResponse(conn2, ":comaster"), t)
+    // timer only
+    assert("(whisper) Only master can switch to game mode!", getResponse(conn1, ":game"), t)
+    getResponse(connM, ":game")
+    assert("(broadcast) ===========10 seconds===========", getResponse(conn1, ":time 10"), t)
+    assert("(broadcast) ===========Paused, 10 seconds left===========", getResponse(conn1, ":pause"), t)
+    assert("(broadcast) Master has handed the game over to (master) Team2",
+           getResponse(connM, ":master give Team2"), t)
+    assert("(whisper) The game has a master already", getResponse(connM, ":master"), t)
+    assert("(broadcast) Team2 has handed the game over to (master) Master",
+           getResponse(conn2, ":master give #1"), t)
+
+    // the co-master takes over once the grace period is over
+    connM.Close()
+    assert("(broadcast) The master has lost connection, waiting 1 second for them to come back",
+           waitForData("(broadcast)"), t)
+    assert("(whisper) The master has lost connection and may come back, try again later",
+           getResponse(conn2, ":master"), t)
+    assert("(broadcast) The master has not come back, (master) Team1 takes over",
+           waitForData("(broadcast)"), t)
+    assert("(broadcast) Team1 is no longer the master of the game", getResponse(conn1, ":master resign"), t)
+
+    // no co-masters: the countdown waits for a new master
+    getResponse(conn2, ":master")
+    assert("(broadcast) ===========10 seconds===========", getResponse(conn2, ":resume"), t)
+    conn2.Close()
+    waitForData("(broadcast) The master has lost connection")
+    assert("(broadcast) ===========Paused, 9 seconds left===========", waitForData("(broadcast)"), t)
+    assert("(broadcast) The master has not come back, anyone can take over with :master",
+           waitForData("(broadcast)"), t)
+    assert("(broadcast) (master) Team1 is now the master of the game", getResponse(conn1, ":master"), t)
+    stopServer(s)
+}
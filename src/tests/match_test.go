@@ -0,0 +1,55 @@
+package tests
+
+import (
+    "encoding/json"
+    "events"
+    "fmt"
+    "net"
+    "server"
+    "testing"
+)
+
+// answers the current question of the match and gets it accepted
+func winQuestion(connM, conn net.Conn, name string, score int, t *testing.T) {
+    getResponse(connM, ":time 10")
+    assert("(broadcast) " + name + ", your answer?", getResponse(conn, "\n"), t)
+    getResponse(conn, "42")
+    assert(fmt.Sprintf("(broadcast) %s is right! Score: %d", name, score),
+           getResponse(connM, ":accept"), t)
+}
+
+func TestMatch(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    connA := enter("Alpha", false, t)
+    connB := enter("Beta", false, t)
+    connC := enter("Gamma", false, t)
+    assert("(whisper) Cannot find team 'Delta': no such client",
+           getResponse(connM, ":match Alpha vs Delta"), t)
+    assert("(broadcast) ===========Match Alpha vs Beta: first to 2 points, at most 2 questions===========",
+           getResponse(connM, ":match Alpha vs Beta questions=2 target=2"), t)
+    assert("(broadcast) Question 1 of 2", waitForAnyData(), t)
+    getResponse(connM, ":time 10")
+    // spectators don't play
+    assert("(whisper) You can't press button now", getResponse(connC, "\n"), t)
+    assert("(broadcast) Alpha, your answer?", getResponse(connA, "\n"), t)
+    getResponse(connA, "42")
+    getResponse(connM, ":accept")
+    assert("(broadcast) Score: Alpha 1 - 0 Beta", waitForAnyData(), t)
+    assert("(broadcast) Question 2 of 2", waitForAnyData(), t)
+    winQuestion(connM, connB, "Beta", 1, t)
+    assert("(broadcast) Score: Alpha 1 - 1 Beta", waitForAnyData(), t)
+    assert("(broadcast) Tie! Extra question 1", waitForAnyData(), t)
+    assert("(whisper) Tie! Extra question 1. Score: Alpha 1 - 1 Beta", getResponse(connC, ":match"), t)
+    winQuestion(connM, connA, "Alpha", 2, t)
+    assert("(broadcast) Score: Alpha 2 - 1 Beta", waitForAnyData(), t)
+    e := waitForEvent(events.MatchEnd)
+    var info server.MatchInfo
+    json.Unmarshal([]byte(e.Payload), &info)
+    assert("Alpha", info.Winner, t)
+    assert("Alpha", e.Target, t)
+    assert("(broadcast) ===========Alpha wins the match against Beta, 2:1===========",
+           waitForAnyData(), t)
+    assert("(broadcast) ===========Chat Mode On===========", waitForAnyData(), t)
+    stopServer(s)
+}
@@ -0,0 +1,36 @@
+package tests
+
+import (
+    "events"
+    "strings"
+    "testing"
+)
+
+func TestRename(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    // extra spaces are squeezed
+    assert("(broadcast) Team1 is now known as Arthur Dent", getResponse(conn1, ":rename  Arthur   Dent "), t)
+    assert("(whisper) Cannot rename to 'arthur dent': the name is taken",
+           getResponse(connM, ":rename arthur dent"), t)
+    assert("(whisper) Cannot rename to '(master) Boss': the name looks like a server one",
+           getResponse(conn1, ":rename (master) Boss"), t)
+    assert("(whisper) Cannot rename to '#1': the name looks like a server one",
+           getResponse(conn1, ":rename #1"), t)
+    assert("(whisper) Cannot rename to 'a\x07b': the name has control characters",
+           getResponse(conn1, ":rename a\x07b"), t)
+    long := strings.Repeat("x", 33)
+    assert("(whisper) Cannot rename to '" + long + "': the name is longer than 32 characters",
+           getResponse(conn1, ":rename " + long), t)
+    assert("(whisper) Cannot rename to '': the name is empty", getResponse(conn1, ":rename"), t)
+    // renaming to one's own name in another case is fine
+    assert("(broadcast) Arthur Dent is now known as arthur dent",
+           getResponse(conn1, ":rename arthur dent"), t)
+    // the name stays with the player who may come back
+    disconnect(conn1)
+    waitForEvent(events.Leave)
+    assert("(whisper) Cannot rename to 'Arthur Dent': the name is taken",
+           getResponse(connM, ":rename Arthur Dent"), t)
+    stopServer(s)
+}
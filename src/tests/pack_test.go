@@ -0,0 +1,199 @@
+package tests
+
+import (
+    "archive/zip"
+    "bufio"
+    "events"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "pack"
+    "path/filepath"
+    "protocol"
+    "settings"
+    "strings"
+    "testing"
+)
+
+const testPack = `{"title": "Test pack", "questions": [
+    {"text": "The answer to life, the universe and everything?",
+     "answer": "42", "comment": "Deep Thought"},
+    {"text": "Who wrote it?", "answer": "Douglas Adams"}]}`
+
+func TestQuestionsAndPause(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "packs")
+    defer os.RemoveAll(dir)
+    ioutil.WriteFile(filepath.Join(dir, "test.json"), []byte(testPack), 0644)
+    defer func(packDir string) { settings.PackDir = packDir }(settings.PackDir)
+    settings.PackDir = dir
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    readerM := bufio.NewReader(connM)
+    reader1 := bufio.NewReader(conn1)
+    fmt.Fprintf(connM, "%s on\n", protocol.Subscribe)
+    fmt.Fprintf(conn1, "%s on\n", protocol.Subscribe)
+    readLine(readerM, connM, protocol.Roster)
+    readLine(reader1, conn1, protocol.Roster)
+    getResponse(connM, ":game")
+    assert("(whisper) Load a pack first!", getResponse(connM, ":next"), t)
+    assert("(whisper) Only master can load question packs!",
+           getResponse(conn1, ":pack test.json"), t)
+    assert("(broadcast) Pack 'Test pack' loaded: 2 questions",
+           getResponse(connM, ":pack ../test.json"), t)
+    assert("(broadcast) Question 1: The answer to life, the universe and everything?",
+           getResponse(connM, ":next"), t)
+    // the answer goes to the master only
+    e, _ := protocol.DecodeEvent(readLine(readerM, connM, protocol.Event + ` {"kind":"questioninfo"`))
+    q := fmt.Sprint(e.Private, " ", e.Payload)
+    assert(`true {"number":1,"text":"The answer to life, the universe and everything?",` +
+           `"answer":"42","comment":"Deep Thought"}`, q, t)
+    getResponse(connM, ":time 10")
+    assert("(broadcast) ===========Paused, 10 seconds left===========",
+           getResponse(connM, ":pause"), t)
+    assert("(whisper) The countdown is paused", getResponse(conn1, "\n"), t)
+    assert("(broadcast) ===========10 seconds===========", getResponse(connM, ":resume"), t)
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, "\n"), t)
+    getResponse(connM, ":next")
+    for {
+        e, ok := protocol.DecodeEvent(readLine(reader1, conn1, protocol.Event))
+        if !ok || e.Kind == events.Question {
+            break
+        }
+        if e.Private {
+            t.Errorf("Player has got a private event: %s", e.Payload)
+        }
+    }
+    // nor does a master who has handed over
+    getResponse(connM, ":master give Team1")
+    for {
+        e, ok := protocol.DecodeEvent(readLine(readerM, connM, protocol.Event))
+        if !ok || e.Kind == events.Master {
+            break
+        }
+    }
+    getResponse(conn1, ":pack ../test.json")
+    getResponse(conn1, ":next")
+    for {
+        e, ok := protocol.DecodeEvent(readLine(readerM, connM, protocol.Event))
+        if !ok || e.Kind == events.Question {
+            break
+        }
+        if e.Private {
+            t.Errorf("Former master has got a private event: %s", e.Payload)
+        }
+    }
+    stopServer(s)
+}
+
+const dbPack = `Чемпионат:
+Кубок Москвы
+
+Вопрос 1:
+Как зовут
+    этого поэта?
+
+Ответ:
+Пушкин.
+
+Зачёт: Александр Сергеевич.
+
+Комментарий:
+Солнце русской
+поэзии.
+
+Вопрос 2:
+Без ответа.
+
+Автор: Кто-то
+`
+
+const siqContent = `<?xml version="1.0" encoding="utf-8"?>
+<package name="Своя игра" version="4" xmlns="http://vladimirkhil.com/ygpackage3.0.xsd">
+  <rounds>
+    <round name="1 раунд">
+      <themes>
+        <theme name="Поэты">
+          <questions>
+            <question price="100">
+              <scenario>
+                <atom>Кто написал «Онегина»?</atom>
+                <atom type="image">@onegin.jpg</atom>
+                <atom type="marker" />
+                <atom type="image">@pushkin.jpg</atom>
+              </scenario>
+              <right><answer>Пушкин</answer><answer>А. С. Пушкин</answer></right>
+              <info><sources><source>Википедия</source></sources></info>
+            </question>
+%s
+          </questions>
+        </theme>
+      </themes>
+    </round>
+  </rounds>
+</package>
+`
+
+const siqNoAnswer = `            <question price="200">
+              <scenario><atom>Без ответа</atom></scenario>
+            </question>`
+
+func writeSIQ(path string, content string) {
+    f, _ := os.Create(path)
+    archive := zip.NewWriter(f)
+    w, _ := archive.Create("content.xml")
+    w.Write([]byte(content))
+    archive.Close()
+    f.Close()
+}
+
+func TestImport(t *testing.T) {
+    dir, _ := ioutil.TempDir("", "packs")
+    defer os.RemoveAll(dir)
+    path := filepath.Join(dir, "chgk.txt")
+    ioutil.WriteFile(path, []byte(dbPack), 0644)
+    _, err := pack.Load(path)
+    assert(path + ":17: question 2 has no answer", fmt.Sprint(err), t)
+    ioutil.WriteFile(path, []byte(dbPack[:strings.Index(dbPack, "Вопрос 2:")]), 0644)
+    p, err := pack.Load(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    assert("Кубок Москвы 1", fmt.Sprint(p.Title, " ", len(p.Questions)), t)
+    assert("{1 Как зовут этого поэта? Пушкин. Александр Сергеевич. Солнце русской поэзии.  }",
+           fmt.Sprint(p.Questions[0]), t)
+    // old files of the database are in windows-1251
+    ioutil.WriteFile(path, []byte{0xc2, 0xee, 0xef, 0xf0, 0xee, 0xf1, ':', '\n', '?', '\n',
+                                  0xce, 0xf2, 0xe2, 0xe5, 0xf2, ':', ' ', '!', '\n'}, 0644)
+    p, err = pack.Load(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    assert("? !", p.Questions[0].Text + " " + p.Questions[0].Answer, t)
+
+    path = filepath.Join(dir, "game.siq")
+    writeSIQ(path, fmt.Sprintf(siqContent, siqNoAnswer))
+    _, err = pack.Load(path)
+    assert(path + ":content.xml:18: question has no answer", fmt.Sprint(err), t)
+    writeSIQ(path, fmt.Sprintf(siqContent, ""))
+    p, err = pack.Load(path)
+    if err != nil {
+        t.Fatal(err)
+    }
+    assert("Своя игра 1", fmt.Sprint(p.Title, " ", len(p.Questions)), t)
+    // the picture after the marker goes with the answer
+    assert("{1 Поэты, 100: Кто написал «Онегина»? [image: onegin.jpg] Пушкин А. С. Пушкин [image: pushkin.jpg] Википедия }",
+           fmt.Sprint(p.Questions[0]), t)
+    writeSIQ(path, siqContent[:strings.Index(siqContent, "<themes>")])
+    _, err = pack.Load(path)
+    assert(path + ":content.xml:5: unexpected EOF", fmt.Sprint(err), t)
+
+    path = filepath.Join(dir, "game.json")
+    ioutil.WriteFile(path, []byte(`{"title": "Test", "questions": [
+    {"text": "Who wrote Onegin?", "answer": "Pushkin"},
+
+    {"text": "Who wrote Dead Souls?"}
+]}`), 0644)
+    _, err = pack.Load(path)
+    assert(path + ":4: question 2 has no answer", fmt.Sprint(err), t)
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+    "fmt"
+    _ "rules"
+    "server"
+    "settings"
+    "testing"
+    "time"
+)
+
+// tells what hooks have been called
+type recorder struct {
+    calls chan string
+}
+
+func (r *recorder) OnJoin(game *server.Game, client *server.Client) {
+    r.calls <- "join " + client.GetName()
+}
+
+func (r *recorder) OnPress(game *server.Game, client *server.Client, after time.Duration) {
+    r.calls <- "press " + client.GetName()
+}
+
+func (r *recorder) OnAnswer(game *server.Game, client *server.Client, answer string) {
+    r.calls <- "answer " + client.GetName() + " " + answer
+}
+
+func (r *recorder) OnTimeout(game *server.Game) {
+    r.calls <- "timeout"
+}
+
+func (r *recorder) OnJudgement(game *server.Game, j *server.Judgement) {
+    r.calls <- fmt.Sprintf("judgement %s %v %d", j.Player.GetName(), j.Correct, j.Points)
+}
+
+func (r *recorder) OnRoundEnd(game *server.Game, round int) {
+    r.calls <- fmt.Sprintf("round %d of %d", round, game.Rounds())
+}
+
+var calls = make(chan string, 100)
+
+var errRecorder = server.RegisterPlugin("recorder", func(options server.Options) (server.Hooks, error) {
+    return &recorder{calls}, nil
+})
+
+func expectCalls(t *testing.T, expected ...string) {
+    for _, call := range expected {
+        select {
+        case actual := <-calls:
+            assert(call, actual, t)
+        case <-time.After(waitTimeout):
+            t.Errorf("Expected hook call '%s'", call)
+            return
+        }
+    }
+}
+
+func TestPlugins(t *testing.T) {
+    if errRecorder != nil {
+        t.Fatalf("Cannot register the recorder: %s", errRecorder)
+    }
+    defer func(plugins string) { settings.Plugins = plugins }(settings.Plugins)
+    settings.Plugins = "recorder; quickbonus within=5; lastround factor=3"
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    connA := enter("Alpha", false, t)
+    connB := enter("Beta", false, t)
+    expectCalls(t, "join anonymous player 1", "join anonymous player 2", "join anonymous player 3")
+
+    getResponse(connM, ":game")
+    getResponse(connM, ":time 1")
+    assert("(broadcast) ===========Time is Out===========", waitForAnyData(), t)
+    expectCalls(t, "timeout", "round 1 of 0")
+
+    // the last of two questions counts three times, quick answers get a point more
+    getResponse(connM, ":match Alpha vs Beta questions=2")
+    assert("(broadcast) Question 1 of 2", waitForAnyData(), t)
+    getResponse(connM, ":time 10")
+    assert("(broadcast) Alpha, your answer?", getResponse(connA, "\n"), t)
+    getResponse(connA, "42")
+    assert("(broadcast) Quick answer bonus for Alpha: 1 point", getResponse(connM, ":accept"), t)
+    assert("(broadcast) Alpha is right! Score: 2", waitForAnyData(), t)
+    assert("(broadcast) Score: Alpha 2 - 0 Beta", waitForAnyData(), t)
+    assert("(broadcast) Points of this round count 3 times", waitForAnyData(), t)
+    assert("(broadcast) Question 2 of 2", waitForAnyData(), t)
+    expectCalls(t, "press Alpha", "answer Alpha 42", "judgement Alpha true 1", "round 1 of 2")
+
+    getResponse(connM, ":time 10")
+    getResponse(connB, "\n")
+    getResponse(connB, "41")
+    assert("(broadcast) Beta is wrong", getResponse(connM, ":reject"), t)
+    assert("(broadcast) Score: Alpha 2 - 0 Beta", waitForAnyData(), t)
+    assert("(broadcast) Alpha, your answer?", getResponse(connA, "\n"), t)
+    getResponse(connA, "42")
+    getResponse(connM, ":accept")
+    assert("(broadcast) Alpha is right! Score: 8", waitForAnyData(), t)
+    expectCalls(t, "press Beta", "answer Beta 41", "judgement Beta false 0",
+                "press Alpha", "answer Alpha 42", "judgement Alpha true 1", "round 2 of 2")
+    stopServer(s)
+}
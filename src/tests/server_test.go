@@ -1,16 +1,28 @@
 package tests
 
 import (
+    "bufio"
+    "events"
     "fmt"
+    "logger"
     "net"
+    "protocol"
     "server"
     "testing"
     "settings"
     "strings"
+    "time"
     "utils"
 )
 
-var stateCh chan string = make(chan string)
+// how long to wait for an expected event before giving up
+var waitTimeout time.Duration = 10 * time.Second
+
+var sub *events.Subscription
+
+func init() {
+    logger.SetDefault(logger.Quiet())
+}
 
 func assert(expected string, actual string, t *testing.T) {
     if actual != expected {
@@ -18,15 +30,31 @@ func assert(expected string, actual string, t *testing.T) {
     }
 }
 
+func waitForEvent(kind events.Kind) events.Event {
+    timeout := time.After(waitTimeout)
+    for {
+        select {
+        case e := <-sub.C:
+            if e.Kind == kind {
+                return e
+            }
+        case <-timeout:
+            return events.Event{}
+        }
+    }
+}
+
+// returns next text message in the "(broadcast) text" form
 func waitForAnyData() string {
+    timeout := time.After(waitTimeout)
     for {
         select {
-        case data:= <- stateCh:
-            if strings.HasSuffix(data, string(settings.EOL)) {
-                data = strings.Replace(data, string(settings.EOL), "", 1)
+        case e := <-sub.C:
+            if e.Kind.IsMessage() {
+                return e.String()
             }
-            return fmt.Sprintf("%s", data)
-        default:
+        case <-timeout:
+            return ""
         }
     }
 }
@@ -34,7 +62,7 @@ func waitForAnyData() string {
 func waitForData(msgType string) string {
     for {
         data := waitForAnyData()
-        if strings.HasPrefix(data, msgType) {
+        if data == "" || strings.HasPrefix(data, msgType) {
             return data
         }
     }
@@ -74,19 +102,23 @@ func getResponse(conn net.Conn, data string) string {
     if !strings.HasSuffix(data, string(settings.EOL)) {
         data = data + string(settings.EOL)
     }
-    fmt.Fprintf(conn, data)
+    fmt.Fprint(conn, data)
     return waitForAnyData()
 }
 
 func startServer() (*server.Server, string) {
-    s := server.NewServer("127.0.0.1", 9999, stateCh)
+    s := server.NewServer("127.0.0.1", 9999)
+    sub = s.Events.Subscribe(1024)
     go s.Start()
     return s, waitForData("(system)")
 }
 
+// returns once the listener is closed and the port can be reused
 func stopServer(s *server.Server) string {
     go s.Stop()
-    return waitForData("(system)")
+    data := waitForData("(system) Server shutdown")
+    sub.Close()
+    return data
 }
 
 func TestChatCommands(t *testing.T) {
@@ -221,11 +253,9 @@ func TestTimingIssues(t *testing.T) {
 }
 
 func TestConnectDisconnect(t *testing.T) {
-    s, _ := startServer()
-    // mind that at least one active connection
-    // should remain to use waitForData
+    s, launched := startServer()
+    assert("(system) Launching Brain Server...", launched, t)
     c1, _ := net.Dial("tcp", "127.0.0.1:9999")
-    assert("(system) Launching Brain Server...", waitForData("(system)"), t)
     assert(fmt.Sprintf("(system) 'anonymous player 1' has joined (%s). Total clients: 1",
         c1.LocalAddr()),
         waitForData("(system)"), t)
@@ -241,3 +271,100 @@ func TestConnectDisconnect(t *testing.T) {
            waitForData("(system)"), t)
     stopServer(s)
 }
+
+func TestEvents(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    fmt.Fprint(conn1, ":game\n")
+    e := waitForEvent(events.Whisper)
+    assert("Team1", e.Target, t)
+    assert(settings.DefaultRoom, e.Room, t)
+    fmt.Fprint(connM, ":game\n")
+    e = waitForEvent(events.Mode)
+    assert("Master", e.Actor, t)
+    assert("game", e.Payload, t)
+    fmt.Fprint(connM, ":time 10\n")
+    assert("10", waitForEvent(events.TimerStart).Payload, t)
+    fmt.Fprint(conn1, "\n")
+    assert("Team1", waitForEvent(events.Press).Actor, t)
+    fmt.Fprint(conn1, "42\n")
+    assert("42", waitForEvent(events.Answer).Payload, t)
+    stopServer(s)
+}
+
+// reads lines sent to conn until one starts with prefix
+func readLine(reader *bufio.Reader, conn net.Conn, prefix string) string {
+    conn.SetReadDeadline(time.Now().Add(waitTimeout))
+    defer conn.SetReadDeadline(time.Time{})
+    for {
+        line, err := reader.ReadString(settings.EOL)
+        if err != nil {
+            return ""
+        }
+        if strings.HasPrefix(line, prefix) {
+            return line
+        }
+    }
+}
+
+func TestEventFeed(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    reader := bufio.NewReader(conn1)
+    fmt.Fprintf(conn1, "%s on\n", protocol.Subscribe)
+    e, _ := protocol.DecodeEvent(readLine(reader, conn1, protocol.Event))
+    assert("chat", e.Payload, t)
+    players, _ := protocol.DecodeRoster(readLine(reader, conn1, protocol.Roster))
+    assert("[{Master master 0} {Team1 player 0}]", fmt.Sprint(players), t)
+    getResponse(connM, ":game")
+    getResponse(connM, ":time 10")
+    for _, kind := range []events.Kind{events.Mode, events.TimerStart, events.Cue} {
+        e, _ = protocol.DecodeEvent(readLine(reader, conn1, protocol.Event))
+        assert(string(kind), string(e.Kind), t)
+    }
+    // text messages are not duplicated as events
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, "\n"), t)
+    e, _ = protocol.DecodeEvent(readLine(reader, conn1, protocol.ControlPrefix))
+    assert("press Team1", fmt.Sprintf("%s %s", e.Kind, e.Actor), t)
+    stopServer(s)
+}
+
+func TestSessionResume(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    reader := bufio.NewReader(connM)
+    token := strings.TrimSpace(strings.TrimPrefix(
+        readLine(reader, connM, protocol.Session), protocol.Session))
+    conn1 := enter("Team1", false, t)
+    getResponse(connM, ":game")
+    connM.Close()
+    waitForEvent(events.Leave)
+    conn2, _ := connect()
+    assert("(whisper) Cannot resume the session, it has expired",
+           getResponse(conn2, protocol.ResumeSession + " nosuchtoken"), t)
+    connM, _ = connect()
+    assert("(broadcast) anonymous player 4 is back as (master) Master",
+           getResponse(connM, protocol.ResumeSession + " " + token), t)
+    // master rights are back
+    assert("(broadcast) ===========10 seconds===========", getResponse(connM, ":time 10"), t)
+    assert("(broadcast) Team1, your answer?", getResponse(conn1, "\n"), t)
+    stopServer(s)
+}
+
+func TestExitRoom(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    defer disconnect(conn1)
+    assert("(whisper) Only master can close the room!", getResponse(conn1, ":exit"), t)
+    getResponse(connM, ":join side")
+    waitForData("(broadcast) 'Master' has joined us in room side!")
+    getResponse(connM, ":master")
+    assert("(broadcast) Room side will be closed!", getResponse(connM, ":exit"), t)
+    waitForData("(system) Done!")
+    // the lobby plays on
+    assert("(broadcast) [Team1] still here", getResponse(conn1, "still here"), t)
+    stopServer(s)
+}
@@ -0,0 +1,115 @@
+package tests
+
+import (
+    "admin"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "tournament"
+)
+
+// plays the tournament out, the better seed always wins
+func playOut(tour *tournament.Tournament, t *testing.T) {
+    seed := make(map[string]int)
+    for i, team := range tour.Teams {
+        seed[team] = i
+    }
+    for !tour.Over() {
+        playable := tour.Playable()
+        if len(playable) == 0 {
+            t.Fatalf("Tournament is stuck:\n%v", tour.Messages())
+        }
+        for _, m := range playable {
+            tour.Assign(m.Id, "room")
+            winner := 0
+            if seed[m.Teams[1]] < seed[m.Teams[0]] {
+                winner = 1
+            }
+            if err := tour.Record(m.Id, winner, [2]int{1, 0}); err != nil {
+                t.Fatal(err)
+            }
+        }
+    }
+}
+
+func TestBrackets(t *testing.T) {
+    teams := []string{"A", "B", "C", "D", "E"}
+    for _, c := range []struct {
+        format tournament.Format
+        teams int
+        matches int
+    }{
+        {tournament.SingleElimination, 5, 7},
+        {tournament.SingleElimination, 2, 1},
+        {tournament.DoubleElimination, 4, 6},
+        {tournament.DoubleElimination, 5, 14},
+        {tournament.RoundRobin, 5, 10},
+        {tournament.Swiss, 5, 9},
+    } {
+        tour, err := tournament.New(c.format, teams[:c.teams])
+        if err != nil {
+            t.Fatal(err)
+        }
+        playOut(tour, t)
+        name := fmt.Sprintf("%s/%d", c.format, c.teams)
+        assert(name + " A", name + " " + tour.Champion(), t)
+        assert(fmt.Sprintf("%s %d", name, c.matches), fmt.Sprintf("%s %d", name, len(tour.Matches)), t)
+    }
+    tour, _ := tournament.New(tournament.RoundRobin, teams[:2])
+    playOut(tour, t)
+    msgs := tour.Messages()
+    assert("Турнир (roundrobin), 2 команды", msgs[0].In("ru"), t)
+    assert("  №1 A против B: A побеждает 1:0", msgs[2].In("ru"), t)
+    assert("  1. A: 1-0, 1 point", msgs[4].In("en"), t)
+    assert("  2. B: 0-1, 0 очков", msgs[5].In("ru"), t)
+    if _, err := tournament.New(tournament.Swiss, []string{"A", "A"}); err != tournament.ErrDuplicateTeam {
+        t.Errorf("Duplicate teams accepted")
+    }
+}
+
+func TestTournament(t *testing.T) {
+    s, _ := startServer()
+    api := httptest.NewServer(admin.New(s, ""))
+    defer api.Close()
+    connM := enter("Master", true, t)
+    connA := enter("Alpha", false, t)
+    defer disconnect(enter("Beta", false, t))
+    assert("(whisper) Only master can run tournaments!",
+           getResponse(connA, ":tournament add Alpha"), t)
+    assert("(broadcast) Team Alpha is registered for the tournament",
+           getResponse(connM, ":tournament add Alpha"), t)
+    assert("(broadcast) Team Beta is registered for the tournament",
+           getResponse(connM, ":tournament add Beta"), t)
+    assert("(broadcast) ===========The tournament (single) has started: 2 teams, 1 match===========",
+           getResponse(connM, ":tournament start single questions=1"), t)
+    assert("(broadcast) Match #1 Alpha vs Beta is played in room match-1",
+           waitForData("(broadcast) Match #1"), t)
+    // a referee follows the teams
+    getResponse(connM, ":join match-1")
+    waitForData("(broadcast) 'Master' has joined us in room match-1!")
+    getResponse(connM, ":master")
+    getResponse(connM, ":time 10")
+    assert("(broadcast) Alpha, your answer?", getResponse(connA, "\n"), t)
+    getResponse(connA, "42")
+    getResponse(connM, ":accept")
+    assert("(broadcast) ===========Alpha wins the match against Beta, 1:0===========",
+           waitForData("(broadcast) ==========="), t)
+    assert("(broadcast) #1 Alpha vs Beta: Alpha wins 1:0", waitForData("(broadcast) #1"), t)
+    assert("(broadcast) ===========Alpha wins the tournament!===========",
+           waitForAnyData(), t)
+    assert("(whisper) Tournament (single), 2 teams", getResponse(connA, ":bracket"), t)
+    assert("(whisper) Round 1", waitForAnyData(), t)
+    assert("(whisper)   #1 Alpha vs Beta: Alpha wins 1:0", waitForAnyData(), t)
+    assert("(whisper) Champion: Alpha", waitForAnyData(), t)
+    resp, err := http.Get(api.URL + "/tournament")
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer resp.Body.Close()
+    var info tournament.Info
+    json.NewDecoder(resp.Body).Decode(&info)
+    assert("Alpha", info.Champion, t)
+    stopServer(s)
+}
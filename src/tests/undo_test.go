@@ -0,0 +1,61 @@
+package tests
+
+import (
+    "testing"
+    "time"
+)
+
+func TestUndo(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    conn1 := enter("Team1", false, t)
+    conn2 := enter("Team2", false, t)
+    assert("(whisper) Nothing to undo", getResponse(connM, ":undo"), t)
+    getResponse(connM, ":game")
+    getResponse(connM, ":time 10")
+    getResponse(conn1, "\n")
+    getResponse(conn1, "42")
+    getResponse(connM, ":accept")
+    // a wrong accept
+    assert("(broadcast) (master) Master has undone ':accept'", getResponse(connM, ":undo"), t)
+    assert("(broadcast) Score of Team1: 1 -> 0", waitForAnyData(), t)
+    assert("(broadcast) ===========10 seconds===========", waitForAnyData(), t)
+    assert("(whisper) Only master can undo commands!", getResponse(conn1, ":undo"), t)
+    assert("(broadcast) Team1 is wrong", getResponse(connM, ":reject"), t)
+    // the countdown goes on, Team2 can still answer
+    assert("(broadcast) Team2, your answer?", getResponse(conn2, "\n"), t)
+    assert("(whisper) Nothing to redo", getResponse(connM, ":redo"), t)
+    getResponse(conn2, "43")
+    getResponse(connM, ":accept")
+    getResponse(connM, ":undo")
+    assert("(broadcast) Score of Team2: 1 -> 0", waitForAnyData(), t)
+    waitForData("(broadcast) ===========")
+    assert("(broadcast) (master) Master has redone ':accept'", getResponse(connM, ":redo"), t)
+    assert("(broadcast) Score of Team2: 0 -> 1", waitForAnyData(), t)
+    assert("(broadcast) ===========Countdown cancelled===========", waitForAnyData(), t)
+    // a premature countdown
+    getResponse(connM, ":time 10")
+    getResponse(connM, ":undo")
+    assert("(broadcast) ===========Countdown cancelled===========", waitForAnyData(), t)
+    assert("(broadcast) Team1 has a false start!", getResponse(conn1, "\n"), t)
+    // a match once over stays over
+    getResponse(connM, ":match Team1 vs Team2 questions=1")
+    waitForData("(broadcast) Question 1 of 1")
+    winQuestion(connM, conn1, "Team1", 1, t)
+    waitForData("(broadcast) ===========Chat Mode On")
+    assert("(whisper) Nothing to undo", getResponse(connM, ":undo"), t)
+    stopServer(s)
+}
+
+func TestUndoTimer(t *testing.T) {
+    s, _ := startServer()
+    connM := enter("Master", true, t)
+    getResponse(connM, ":game")
+    getResponse(connM, ":time 1")
+    getResponse(connM, ":time 30")
+    // the old countdown would have run out by now
+    time.Sleep(1200 * time.Millisecond)
+    assert("(broadcast) (master) Master has undone ':time 30'", getResponse(connM, ":undo"), t)
+    assert("(broadcast) ===========1 second===========", waitForAnyData(), t)
+    stopServer(s)
+}
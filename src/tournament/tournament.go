@@ -0,0 +1,591 @@
+package tournament
+
+
+import (
+    "i18n"
+    "sort"
+)
+
+// brackets of matches between registered teams. A tournament knows
+// nothing about rooms or clients: the server asks it which matches can be
+// played, records the results and the winners advance on their own
+
+type Format string
+
+const (
+    SingleElimination Format = "single"
+    // losers get a second chance in the losers bracket, the grand final
+    // is a single match without a reset
+    DoubleElimination Format = "double"
+    RoundRobin Format = "roundrobin"
+    // teams with the same record meet, ceil(log2(teams)) rounds
+    Swiss Format = "swiss"
+)
+
+var Formats = []Format{SingleElimination, DoubleElimination, RoundRobin, Swiss}
+
+// messages, so that players read them in their language
+var ErrUnknownFormat error = i18n.M("tournament.err.format")
+var ErrTooFewTeams error = i18n.M("tournament.err.too_few")
+var ErrDuplicateTeam error = i18n.M("tournament.err.duplicate")
+var ErrNoSuchMatch error = i18n.M("tournament.err.no_match")
+var ErrNotPlayable error = i18n.M("tournament.err.not_playable")
+
+// where the team of a match slot comes from
+type feed struct {
+    match *Match
+    loser bool
+}
+
+func (f *feed) team() string {
+    if f.loser {
+        return f.match.Loser()
+    }
+    return f.match.Winner
+}
+
+type Match struct {
+    Id int `json:"id"`
+    Round int `json:"round"`
+    // "winners", "losers" or "final" in double elimination, empty otherwise
+    Bracket string `json:"bracket,omitempty"`
+    // an empty name is either not known yet or a bye
+    Teams [2]string `json:"teams"`
+    Scores [2]int `json:"scores"`
+    Winner string `json:"winner,omitempty"`
+    // the room the match is played in
+    Room string `json:"room,omitempty"`
+    Done bool `json:"done"`
+    known [2]bool
+    feeds [2]*feed
+}
+
+// both teams are known and the match has not been played yet
+func (m *Match) Ready() bool {
+    return !m.Done && m.known[0] && m.known[1]
+}
+
+func (m *Match) Loser() string {
+    if !m.Done {
+        return ""
+    }
+    if m.Winner == m.Teams[0] {
+        return m.Teams[1]
+    }
+    return m.Teams[0]
+}
+
+func (m *Match) bye() bool {
+    return m.known[0] && m.known[1] && (m.Teams[0] == "" || m.Teams[1] == "")
+}
+
+type Standing struct {
+    Team string `json:"team"`
+    Played int `json:"played"`
+    Wins int `json:"wins"`
+    Losses int `json:"losses"`
+    // points scored in all the matches
+    Points int `json:"points"`
+}
+
+type Tournament struct {
+    Format Format
+    Teams []string
+    Matches []*Match
+    // swiss rounds to play
+    rounds int
+}
+
+// a snapshot safe to hand out, e.g. to be encoded as json
+type Info struct {
+    Format Format `json:"format"`
+    Teams []string `json:"teams"`
+    Matches []Match `json:"matches"`
+    Standings []Standing `json:"standings"`
+    Champion string `json:"champion,omitempty"`
+}
+
+func New(format Format, teams []string) (*Tournament, error) {
+    if len(teams) < 2 {
+        return nil, ErrTooFewTeams
+    }
+    seen := make(map[string]bool)
+    for _, team := range teams {
+        if team == "" || seen[team] {
+            return nil, ErrDuplicateTeam
+        }
+        seen[team] = true
+    }
+    t := &Tournament{Format: format, Teams: append([]string(nil), teams...)}
+    switch format {
+    case SingleElimination:
+        t.winnersBracket("")
+    case DoubleElimination:
+        t.doubleElimination()
+    case RoundRobin:
+        t.roundRobin()
+    case Swiss:
+        for t.rounds = 1; 1 << uint(t.rounds) < len(teams); t.rounds++ {
+        }
+        t.swissRound(1, t.Teams)
+    default:
+        return nil, ErrUnknownFormat
+    }
+    t.advance()
+    return t, nil
+}
+
+func ParseFormat(name string) (Format, error) {
+    for _, format := range Formats {
+        if string(format) == name {
+            return format, nil
+        }
+    }
+    return "", ErrUnknownFormat
+}
+
+func (t *Tournament) newMatch(round int, bracket string) *Match {
+    m := &Match{Id: len(t.Matches) + 1, Round: round, Bracket: bracket}
+    t.Matches = append(t.Matches, m)
+    return m
+}
+
+func (m *Match) setTeam(i int, team string) {
+    m.Teams[i] = team
+    m.known[i] = true
+}
+
+// seeds in bracket order, so that the top ones meet as late as possible:
+// 1 8 4 5 2 7 3 6 for 8 teams
+func bracketOrder(size int) []int {
+    order := []int{1}
+    for n := 1; n < size; n *= 2 {
+        var next []int
+        for _, seed := range order {
+            next = append(next, seed, 2 * n + 1 - seed)
+        }
+        order = next
+    }
+    return order
+}
+
+// knock-out rounds, teams short of a power of two get byes. Returns the
+// matches round by round
+func (t *Tournament) winnersBracket(bracket string) [][]*Match {
+    size := 1
+    for size < len(t.Teams) {
+        size *= 2
+    }
+    order := bracketOrder(size)
+    var rounds [][]*Match
+    var round []*Match
+    for i := 0; i < size; i += 2 {
+        m := t.newMatch(1, bracket)
+        for j := 0; j < 2; j++ {
+            team := ""
+            if seed := order[i + j]; seed <= len(t.Teams) {
+                team = t.Teams[seed - 1]
+            }
+            m.setTeam(j, team)
+        }
+        round = append(round, m)
+    }
+    rounds = append(rounds, round)
+    for len(round) > 1 {
+        var next []*Match
+        for i := 0; i < len(round); i += 2 {
+            m := t.newMatch(len(rounds) + 1, bracket)
+            m.feeds = [2]*feed{{match: round[i]}, {match: round[i + 1]}}
+            next = append(next, m)
+        }
+        rounds = append(rounds, next)
+        round = next
+    }
+    return rounds
+}
+
+func (t *Tournament) doubleElimination() {
+    winners := t.winnersBracket("winners")
+    // the one to meet the winners bracket champion in the grand final
+    lastChance := &feed{match: winners[0][0], loser: true}
+    if len(winners) > 1 {
+        var round []*Match
+        for i := 0; i < len(winners[0]); i += 2 {
+            m := t.newMatch(1, "losers")
+            m.feeds = [2]*feed{{match: winners[0][i], loser: true},
+                               {match: winners[0][i + 1], loser: true}}
+            round = append(round, m)
+        }
+        number := 1
+        for r := 1; r < len(winners); r++ {
+            // survivors meet those who have just lost in the winners
+            // bracket, in reverse order to put off rematches
+            number++
+            var minor []*Match
+            for i, survivor := range round {
+                m := t.newMatch(number, "losers")
+                dropped := winners[r][len(winners[r]) - 1 - i]
+                m.feeds = [2]*feed{{match: survivor}, {match: dropped, loser: true}}
+                minor = append(minor, m)
+            }
+            round = minor
+            if r == len(winners) - 1 {
+                break
+            }
+            number++
+            var major []*Match
+            for i := 0; i < len(round); i += 2 {
+                m := t.newMatch(number, "losers")
+                m.feeds = [2]*feed{{match: round[i]}, {match: round[i + 1]}}
+                major = append(major, m)
+            }
+            round = major
+        }
+        lastChance = &feed{match: round[0]}
+    }
+    final := t.newMatch(1, "final")
+    champion := winners[len(winners) - 1][0]
+    final.feeds = [2]*feed{{match: champion}, lastChance}
+}
+
+// circle method: one team stays, the others rotate
+func (t *Tournament) roundRobin() {
+    teams := append([]string(nil), t.Teams...)
+    if len(teams) % 2 == 1 {
+        teams = append(teams, "")
+    }
+    n := len(teams)
+    for round := 1; round < n; round++ {
+        for i := 0; i < n / 2; i++ {
+            a, b := teams[i], teams[n - 1 - i]
+            if a == "" || b == "" {
+                continue
+            }
+            m := t.newMatch(round, "")
+            m.setTeam(0, a)
+            m.setTeam(1, b)
+        }
+        // keep the first one, rotate the rest
+        last := teams[n - 1]
+        copy(teams[2:], teams[1:n - 1])
+        teams[1] = last
+    }
+}
+
+// pairs teams ranked best first, avoiding rematches where possible.
+// With an odd number of teams the lowest ranked one without a bye sits out
+func (t *Tournament) swissRound(round int, ranked []string) {
+    ranked = append([]string(nil), ranked...)
+    if len(ranked) % 2 == 1 {
+        for i := len(ranked) - 1; i >= 0; i-- {
+            if !t.hadBye(ranked[i]) || i == 0 {
+                m := t.newMatch(round, "")
+                m.setTeam(0, ranked[i])
+                m.setTeam(1, "")
+                ranked = append(ranked[:i], ranked[i + 1:]...)
+                break
+            }
+        }
+    }
+    if round == 1 {
+        // top half against bottom half
+        half := len(ranked) / 2
+        for i := 0; i < half; i++ {
+            m := t.newMatch(round, "")
+            m.setTeam(0, ranked[i])
+            m.setTeam(1, ranked[i + half])
+        }
+        return
+    }
+    paired := make([]bool, len(ranked))
+    for i := range ranked {
+        if paired[i] {
+            continue
+        }
+        opponent := -1
+        for j := i + 1; j < len(ranked); j++ {
+            if paired[j] {
+                continue
+            }
+            if opponent < 0 {
+                // a rematch if nobody else is left
+                opponent = j
+            }
+            if !t.played(ranked[i], ranked[j]) {
+                opponent = j
+                break
+            }
+        }
+        paired[i], paired[opponent] = true, true
+        m := t.newMatch(round, "")
+        m.setTeam(0, ranked[i])
+        m.setTeam(1, ranked[opponent])
+    }
+}
+
+func (t *Tournament) hadBye(team string) bool {
+    for _, m := range t.Matches {
+        if m.bye() && (m.Teams[0] == team || m.Teams[1] == team) {
+            return true
+        }
+    }
+    return false
+}
+
+func (t *Tournament) played(a string, b string) bool {
+    for _, m := range t.Matches {
+        if m.Teams[0] == a && m.Teams[1] == b || m.Teams[0] == b && m.Teams[1] == a {
+            return true
+        }
+    }
+    return false
+}
+
+// fills in teams of the matches whose feeds are over, lets teams with a
+// bye through and starts the next swiss round once the current one is over
+func (t *Tournament) advance() {
+    for changed := true; changed; {
+        changed = false
+        for _, m := range t.Matches {
+            if m.Done {
+                continue
+            }
+            for i, f := range m.feeds {
+                if f != nil && !m.known[i] && f.match.Done {
+                    m.setTeam(i, f.team())
+                    changed = true
+                }
+            }
+            if m.bye() {
+                m.Winner = m.Teams[0] + m.Teams[1]
+                m.Done = true
+                changed = true
+            }
+        }
+    }
+    if t.Format != Swiss {
+        return
+    }
+    last := t.Matches[len(t.Matches) - 1].Round
+    if last >= t.rounds || !t.roundOver(last) {
+        return
+    }
+    var ranked []string
+    for _, s := range t.Standings() {
+        ranked = append(ranked, s.Team)
+    }
+    t.swissRound(last + 1, ranked)
+    t.advance()
+}
+
+func (t *Tournament) roundOver(round int) bool {
+    for _, m := range t.Matches {
+        if m.Round == round && !m.Done {
+            return false
+        }
+    }
+    return true
+}
+
+func (t *Tournament) Match(id int) *Match {
+    if id < 1 || id > len(t.Matches) {
+        return nil
+    }
+    return t.Matches[id - 1]
+}
+
+// a team is busy while it plays a match assigned to a room
+func (t *Tournament) busy(team string) bool {
+    for _, m := range t.Matches {
+        if !m.Done && m.Room != "" && (m.Teams[0] == team || m.Teams[1] == team) {
+            return true
+        }
+    }
+    return false
+}
+
+// matches to be assigned to rooms now: ready, not assigned yet and with
+// both teams free. A team appears in one of them at most
+func (t *Tournament) Playable() []*Match {
+    var playable []*Match
+    taken := make(map[string]bool)
+    for _, m := range t.Matches {
+        if !m.Ready() || m.Room != "" {
+            continue
+        }
+        a, b := m.Teams[0], m.Teams[1]
+        if taken[a] || taken[b] || t.busy(a) || t.busy(b) {
+            continue
+        }
+        taken[a], taken[b] = true, true
+        playable = append(playable, m)
+    }
+    return playable
+}
+
+func (t *Tournament) Assign(id int, room string) error {
+    m := t.Match(id)
+    if m == nil {
+        return ErrNoSuchMatch
+    }
+    if !m.Ready() {
+        return ErrNotPlayable
+    }
+    m.Room = room
+    return nil
+}
+
+// the match has been abandoned, it will be played again
+func (t *Tournament) Unassign(id int) {
+    if m := t.Match(id); m != nil && !m.Done {
+        m.Room = ""
+    }
+}
+
+// winner is 0 or 1, the index of the team in the match
+func (t *Tournament) Record(id int, winner int, scores [2]int) error {
+    m := t.Match(id)
+    if m == nil {
+        return ErrNoSuchMatch
+    }
+    if !m.Ready() || winner < 0 || winner > 1 {
+        return ErrNotPlayable
+    }
+    m.Scores = scores
+    m.Winner = m.Teams[winner]
+    m.Done = true
+    t.advance()
+    return nil
+}
+
+func (t *Tournament) Over() bool {
+    for _, m := range t.Matches {
+        if !m.Done {
+            return false
+        }
+    }
+    return true
+}
+
+// the winner of the last match in knock-out formats, the leader otherwise;
+// empty until the tournament is over
+func (t *Tournament) Champion() string {
+    if !t.Over() {
+        return ""
+    }
+    if t.Format == SingleElimination || t.Format == DoubleElimination {
+        return t.Matches[len(t.Matches) - 1].Winner
+    }
+    return t.Standings()[0].Team
+}
+
+// best first: by wins, then by points, then by seed
+func (t *Tournament) Standings() []Standing {
+    standings := make([]Standing, len(t.Teams))
+    index := make(map[string]int)
+    for i, team := range t.Teams {
+        standings[i].Team = team
+        index[team] = i
+    }
+    for _, m := range t.Matches {
+        if !m.Done {
+            continue
+        }
+        if m.bye() {
+            // a free win in swiss, nothing in knock-outs
+            if t.Format == Swiss {
+                standings[index[m.Winner]].Wins++
+            }
+            continue
+        }
+        for i, team := range m.Teams {
+            s := &standings[index[team]]
+            s.Played++
+            s.Points += m.Scores[i]
+            if team == m.Winner {
+                s.Wins++
+            } else {
+                s.Losses++
+            }
+        }
+    }
+    sort.SliceStable(standings, func(i, j int) bool {
+        if standings[i].Wins != standings[j].Wins {
+            return standings[i].Wins > standings[j].Wins
+        }
+        return standings[i].Points > standings[j].Points
+    })
+    return standings
+}
+
+func (t *Tournament) Info() Info {
+    info := Info{Format: t.Format, Teams: append([]string(nil), t.Teams...),
+                 Standings: t.Standings(), Champion: t.Champion()}
+    for _, m := range t.Matches {
+        info.Matches = append(info.Matches, *m)
+    }
+    return info
+}
+
+// the match in the players' language
+func (m *Match) Message() *i18n.Message {
+    names := [2]interface{}{}
+    for i, team := range m.Teams {
+        switch {
+        case !m.known[i]:
+            names[i] = "?"
+        case team == "":
+            names[i] = i18n.M("tournament.bye")
+        default:
+            names[i] = team
+        }
+    }
+    switch {
+    case m.bye():
+        return i18n.M("tournament.match.bye", m.Id, names[0], names[1], m.Winner)
+    case m.Done:
+        return i18n.M("tournament.match.won", m.Id, names[0], names[1], m.Winner, m.Scores[0], m.Scores[1])
+    case m.Room != "":
+        return i18n.M("tournament.match.room", m.Id, names[0], names[1], m.Room)
+    }
+    return i18n.M("tournament.match", m.Id, names[0], names[1])
+}
+
+func (m *Match) String() string {
+    return m.Message().String()
+}
+
+// the header of the match's round
+func (m *Match) round() *i18n.Message {
+    switch m.Bracket {
+    case "":
+        return i18n.M("tournament.round", m.Round)
+    case "final":
+        return i18n.M("tournament.grand_final")
+    }
+    return i18n.M("tournament.round." + m.Bracket, m.Round)
+}
+
+// the bracket, one line per match grouped by rounds
+func (t *Tournament) Messages() []*i18n.Message {
+    msgs := []*i18n.Message{i18n.M("tournament.bracket", t.Format, i18n.M("tournament.teams.n", len(t.Teams)))}
+    header := ""
+    for _, m := range t.Matches {
+        if h := m.round(); h.String() != header {
+            header = h.String()
+            msgs = append(msgs, h)
+        }
+        msgs = append(msgs, i18n.M("tournament.bracket.match", m.Message()))
+    }
+    if t.Format == RoundRobin || t.Format == Swiss {
+        msgs = append(msgs, i18n.M("tournament.standings"))
+        for i, s := range t.Standings() {
+            msgs = append(msgs, i18n.M("tournament.standing", i + 1, s.Team, s.Wins, s.Losses,
+                                       i18n.M("points", s.Points)))
+        }
+    }
+    if champion := t.Champion(); champion != "" {
+        msgs = append(msgs, i18n.M("tournament.bracket.champion", champion))
+    }
+    return msgs
+}